@@ -0,0 +1,243 @@
+package genie
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+var (
+	ErrArgMissing   = Error("missing required argument")
+	ErrArgInvalid   = Error("invalid argument")
+	ErrArgsTooMany  = Error("too many arguments")
+	ErrArgsNotValid = Error("invalid argument specification")
+	ErrArgsCount    = Error("invalid number of arguments")
+)
+
+// ArgsFunc validates the positional arguments provided to a command, it's called after flags are parsed.
+type ArgsFunc func(command *Command, args []string) error
+
+// Arg describes a positional argument accepted by a command.
+type Arg struct {
+	Name        string
+	Description string
+	Required    bool
+	Variadic    bool       //accepts all remaining values, only the last argument can be variadic
+	Value       flag.Value //optional, Set is called with each value provided for the argument
+}
+
+// ArgError is returned when the positional arguments provided do not satisfy a command's Args.
+type ArgError struct {
+	Path  string //path of the command the arguments were provided to
+	Arg   string //name of the argument, blank when too many arguments are provided
+	Value string //the offending value, if any
+	Err   error
+}
+
+func (e *ArgError) Error() string {
+	switch {
+	case e.Err == ErrArgMissing:
+		return fmt.Sprintf("missing required argument <%s> for %q", e.Arg, e.Path)
+	case e.Err == ErrArgsTooMany:
+		return fmt.Sprintf("too many arguments for %q, unexpected %q", e.Path, e.Value)
+	case e.Arg != "":
+		return fmt.Sprintf("invalid value %q for argument <%s> of %q: %s", e.Value, e.Arg, e.Path, e.Err)
+	}
+
+	return fmt.Sprintf("%s for %q", e.Err, e.Path)
+}
+
+func (e *ArgError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ArgError) As(target interface{}) bool {
+	token := e.Value
+	if token == "" {
+		token = e.Arg
+	}
+
+	return asUsageError(target, &UsageError{Path: e.Path, Token: token, Err: e})
+}
+
+// ArgsCountError is returned by the argument validators when the wrong number of arguments is provided.
+type ArgsCountError struct {
+	Path string //path of the command the arguments were provided to
+	Min  int
+	Max  int //-1 if there is no maximum
+	Got  int
+}
+
+func (e *ArgsCountError) Error() string {
+	expected := ""
+	switch {
+	case e.Min == e.Max:
+		expected = fmt.Sprintf("%d %s", e.Min, pluralArgs(e.Min))
+	case e.Max < 0:
+		expected = fmt.Sprintf("at least %d %s", e.Min, pluralArgs(e.Min))
+	case e.Min <= 0:
+		expected = fmt.Sprintf("at most %d %s", e.Max, pluralArgs(e.Max))
+	default:
+		expected = fmt.Sprintf("between %d and %d arguments", e.Min, e.Max)
+	}
+
+	return fmt.Sprintf("%q expects %s, got %d", e.Path, expected, e.Got)
+}
+
+func (e *ArgsCountError) Unwrap() error {
+	return ErrArgsCount
+}
+
+func (e *ArgsCountError) As(target interface{}) bool {
+	return asUsageError(target, &UsageError{Path: e.Path, Err: e})
+}
+
+func pluralArgs(n int) string {
+	if n == 1 {
+		return "argument"
+	}
+
+	return "arguments"
+}
+
+// NoArgs returns an error if any arguments are provided.
+func NoArgs(command *Command, args []string) error {
+	return RangeArgs(0, 0)(command, args)
+}
+
+// ExactArgs returns an ArgsFunc that requires exactly n arguments.
+func ExactArgs(n int) ArgsFunc {
+	return RangeArgs(n, n)
+}
+
+// MinArgs returns an ArgsFunc that requires at least n arguments.
+func MinArgs(n int) ArgsFunc {
+	return RangeArgs(n, -1)
+}
+
+// MaxArgs returns an ArgsFunc that allows at most n arguments.
+func MaxArgs(n int) ArgsFunc {
+	return RangeArgs(0, n)
+}
+
+// RangeArgs returns an ArgsFunc that requires between min and max arguments inclusive, a negative max means no maximum.
+func RangeArgs(min, max int) ArgsFunc {
+	return func(command *Command, args []string) error {
+		if len(args) < min || (max >= 0 && len(args) > max) {
+			return &ArgsCountError{Path: command.pathOrName(), Min: min, Max: max, Got: len(args)}
+		}
+
+		return nil
+	}
+}
+
+// ComposeArgs returns an ArgsFunc that runs each of the validators in order, returning the first error.
+func ComposeArgs(validators ...ArgsFunc) ArgsFunc {
+	return func(command *Command, args []string) error {
+		for _, validate := range validators {
+			if validate == nil {
+				continue
+			}
+			if err := validate(command, args); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// bindArgs validates the positional arguments against the command's Args, and sets the value of each argument.
+func (c *Command) bindArgs(values []string) error {
+	if len(c.Args) == 0 {
+		return nil
+	}
+
+	path := c.pathOrName()
+	for i, arg := range c.Args {
+		if arg.Variadic && i != len(c.Args)-1 {
+			return &ArgError{Path: path, Arg: arg.Name, Err: ErrArgsNotValid}
+		}
+
+		if i >= len(values) {
+			if arg.Required {
+				return &ArgError{Path: path, Arg: arg.Name, Err: ErrArgMissing}
+			}
+			continue
+		}
+
+		provided := values[i : i+1]
+		if arg.Variadic {
+			provided = values[i:]
+		}
+
+		for _, v := range provided {
+			if arg.Value == nil {
+				continue
+			}
+			if err := arg.Value.Set(v); err != nil {
+				return &ArgError{Path: path, Arg: arg.Name, Value: v, Err: err}
+			}
+		}
+	}
+
+	last := c.Args[len(c.Args)-1]
+	if !last.Variadic && len(values) > len(c.Args) {
+		return &ArgError{Path: path, Value: values[len(c.Args)], Err: ErrArgsTooMany}
+	}
+
+	return nil
+}
+
+// positionalArgs returns the arguments remaining after flags are parsed, without the "--" terminator.
+func (c *Command) positionalArgs(args []string) []string {
+	remaining := args
+	if c.Flags != nil && c.Flags.Parsed() {
+		remaining = c.Flags.Args()
+	}
+
+	raw := c.RawArgs()
+	if raw == nil || len(raw) == len(remaining) {
+		return remaining
+	}
+
+	//the terminator was not consumed by flag parsing, it's the element just before the raw args at the end
+	positional := make([]string, 0, len(remaining))
+	positional = append(positional, remaining[:len(remaining)-len(raw)-1]...)
+	return append(positional, raw...)
+}
+
+// argsSyntax returns the run syntax for the command's Args, e.g. [-flags...] <required> [optional] [variadic...]
+func (c *Command) argsSyntax() string {
+	var builder strings.Builder
+	builder.WriteString("[-flags...]")
+	for _, arg := range c.Args {
+		name := fmt.Sprintf("[%s]", arg.Name)
+		if arg.Required {
+			name = fmt.Sprintf("<%s>", arg.Name)
+		}
+		if arg.Variadic {
+			name += "..."
+		}
+		builder.WriteString(" " + name)
+	}
+
+	return builder.String()
+}
+
+// argsUsage returns a line for each of the command's Args, nameFormat is used to format each argument name.
+func (c *Command) argsUsage(nameFormat string) string {
+	var builder strings.Builder
+	tabWriter := tabwriter.NewWriter(&builder, 0, 0, 4, ' ', tabwriter.DiscardEmptyColumns)
+	for _, arg := range c.Args {
+		required := ""
+		if arg.Required {
+			required = " (required)"
+		}
+		_, _ = tabWriter.Write([]byte(fmt.Sprintf(nameFormat+"\t%s%s\n", arg.Name, arg.Description, required)))
+	}
+
+	_ = tabWriter.Flush()
+	return builder.String()
+}
@@ -0,0 +1,420 @@
+package genie
+
+import (
+	"errors"
+	"flag"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+type intsValue []int
+
+func (i *intsValue) String() string {
+	return ""
+}
+
+func (i *intsValue) Set(s string) error {
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return err
+	}
+	*i = append(*i, v)
+	return nil
+}
+
+type stringValue string
+
+func (s *stringValue) String() string {
+	return string(*s)
+}
+
+func (s *stringValue) Set(v string) error {
+	*s = stringValue(v)
+	return nil
+}
+
+func TestArgError_Error(t *testing.T) {
+	testCases := []struct {
+		name    string
+		subject *ArgError
+		want    string
+	}{
+		{
+			name:    "missing",
+			subject: &ArgError{Path: "magic copy", Arg: "src", Err: ErrArgMissing},
+			want:    `missing required argument <src> for "magic copy"`,
+		},
+		{
+			name:    "too many",
+			subject: &ArgError{Path: "magic copy", Value: "extra", Err: ErrArgsTooMany},
+			want:    `too many arguments for "magic copy", unexpected "extra"`,
+		},
+		{
+			name:    "invalid",
+			subject: &ArgError{Path: "magic copy", Arg: "count", Value: "x", Err: errors.New("not a number")},
+			want:    `invalid value "x" for argument <count> of "magic copy": not a number`,
+		},
+		{
+			name:    "other",
+			subject: &ArgError{Path: "magic copy", Err: ErrArgsNotValid},
+			want:    `invalid argument specification for "magic copy"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.subject.Error()
+			if got != tc.want {
+				t.Errorf("want %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestCommand_bindArgs(t *testing.T) {
+	t.Run("validate args are bound", func(t *testing.T) {
+		var src stringValue
+		var counts intsValue
+		subject := &Command{
+			Name: "copy",
+			Args: []*Arg{
+				{Name: "src", Required: true, Value: &src},
+				{Name: "counts", Variadic: true, Value: &counts},
+			},
+		}
+
+		err := subject.bindArgs([]string{"here", "1", "2", "3"})
+		if err != nil {
+			t.Errorf("[err] want nil, got %s", err)
+		}
+		if src != "here" {
+			t.Errorf("want here, got %s", src)
+		}
+		if !reflect.DeepEqual(counts, intsValue{1, 2, 3}) {
+			t.Errorf("want [1 2 3], got %v", counts)
+		}
+	})
+
+	t.Run("validate optional args can be omitted", func(t *testing.T) {
+		subject := &Command{
+			Name: "copy",
+			Args: []*Arg{
+				{Name: "src", Required: true},
+				{Name: "dest"},
+			},
+		}
+
+		err := subject.bindArgs([]string{"here"})
+		if err != nil {
+			t.Errorf("[err] want nil, got %s", err)
+		}
+	})
+
+	t.Run("validate no spec allows anything", func(t *testing.T) {
+		subject := &Command{Name: "copy"}
+
+		err := subject.bindArgs([]string{"here", "there"})
+		if err != nil {
+			t.Errorf("[err] want nil, got %s", err)
+		}
+	})
+
+	testCases := []struct {
+		name   string
+		args   []*Arg
+		values []string
+		want   error
+	}{
+		{
+			name:   "missing required",
+			args:   []*Arg{{Name: "src", Required: true}, {Name: "dest", Required: true}},
+			values: []string{"here"},
+			want:   ErrArgMissing,
+		},
+		{
+			name:   "too many",
+			args:   []*Arg{{Name: "src", Required: true}},
+			values: []string{"here", "there"},
+			want:   ErrArgsTooMany,
+		},
+		{
+			name:   "invalid value",
+			args:   []*Arg{{Name: "counts", Variadic: true, Value: &intsValue{}}},
+			values: []string{"1", "two"},
+			want:   strconv.ErrSyntax,
+		},
+		{
+			name:   "variadic not last",
+			args:   []*Arg{{Name: "counts", Variadic: true}, {Name: "dest"}},
+			values: []string{"1"},
+			want:   ErrArgsNotValid,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			subject := &Command{Name: "copy", Args: tc.args}
+			subject.AnchorPaths()
+
+			got := subject.bindArgs(tc.values)
+			if !errors.Is(got, tc.want) {
+				t.Errorf("want %s, got %v", tc.want, got)
+			}
+			var argErr *ArgError
+			if !errors.As(got, &argErr) {
+				t.Fatalf("want *ArgError, got %T", got)
+			}
+			if argErr.Path != "copy" {
+				t.Errorf("want copy, got %s", argErr.Path)
+			}
+		})
+	}
+}
+
+func TestCommand_positionalArgs(t *testing.T) {
+	testCases := []struct {
+		name  string
+		flags bool
+		args  []string
+		want  []string
+	}{
+		{name: "flags and args", flags: true, args: []string{"-flag", "value", "one", "two"}, want: []string{"one", "two"}},
+		{name: "terminator consumed", flags: true, args: []string{"-flag", "value", "--", "-one", "two"}, want: []string{"-one", "two"}},
+		{name: "terminator after args", flags: true, args: []string{"one", "--", "-two"}, want: []string{"one", "-two"}},
+		{name: "no flags", flags: false, args: []string{"one", "--", "--", "-two"}, want: []string{"one", "--", "-two"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			subject := &Command{
+				Name: "command",
+				Run: func(command *Command) error {
+					return nil
+				},
+			}
+			if tc.flags {
+				subject.Flags = flag.NewFlagSet("command", flag.ContinueOnError)
+				subject.Flags.String("flag", "", "testing flags")
+			}
+
+			err := subject.run(tc.args)
+			if err != nil {
+				t.Errorf("[err] want nil, got %s", err)
+			}
+			got := subject.positionalArgs(tc.args)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestCommand_argsSyntax(t *testing.T) {
+	t.Run("validate syntax", func(t *testing.T) {
+		want := "[-flags...] <src> [mode] [dest]..."
+		subject := &Command{
+			Name: "copy",
+			Args: []*Arg{
+				{Name: "src", Required: true},
+				{Name: "mode"},
+				{Name: "dest", Variadic: true},
+			},
+		}
+
+		got := subject.argsSyntax()
+		if got != want {
+			t.Errorf("want %s, got %s", want, got)
+		}
+	})
+}
+
+func Test_DefaultCommandRunner_args(t *testing.T) {
+	t.Run("validate args are validated before check", func(t *testing.T) {
+		want := ErrArgMissing
+		subject := NewCommand("copy", true)
+		subject.Args = []*Arg{{Name: "src", Required: true}}
+		subject.Check = func(command *Command) error {
+			t.Error("unexpected check")
+			return nil
+		}
+		subject.Run = func(command *Command) error {
+			t.Error("unexpected run")
+			return nil
+		}
+
+		got := DefaultCommandRunner(subject, []string{})
+		if !errors.Is(got, want) {
+			t.Errorf("want %s, got %v", want, got)
+		}
+	})
+
+	t.Run("validate args are bound before run", func(t *testing.T) {
+		var src stringValue
+		subject := NewCommand("copy", true)
+		subject.Args = []*Arg{{Name: "src", Required: true, Value: &src}}
+		subject.Run = func(command *Command) error {
+			if src != "here" {
+				t.Errorf("want here, got %s", src)
+			}
+			return nil
+		}
+
+		err := DefaultCommandRunner(subject, []string{"here"})
+		if err != nil {
+			t.Errorf("[err] want nil, got %s", err)
+		}
+	})
+}
+
+func Test_DefaultUsage_args(t *testing.T) {
+	t.Run("validate default usage with args", func(t *testing.T) {
+		want := `Copy things.
+
+USAGE:
+copy [-flags...] <src> [dest]...
+
+FLAGS:
+--help        display help for command
+
+ARGUMENTS:
+src     the source (required)
+dest    the destinations
+Extra argument info.
+`
+		subject := NewCommand("copy", false)
+		subject.Description = "Copy things."
+		subject.Args = []*Arg{
+			{Name: "src", Description: "the source", Required: true},
+			{Name: "dest", Description: "the destinations", Variadic: true},
+		}
+		subject.ArgInfo = "Extra argument info."
+
+		got := subject.ShowUsage()
+		if got != want {
+			t.Errorf("want: %s got: %s", want, got)
+		}
+	})
+
+	t.Run("validate default marked usage with args", func(t *testing.T) {
+		want := `::DESCRIPTION::Copy things.::DESCRIPTION-END::
+
+::HEADER::USAGE:::HEADER-END::
+copy <src> <dest>
+
+::HEADER::FLAGS:::HEADER-END::
+::FLAG::--help::FLAG-END::        display help for command
+
+::HEADER::ARGUMENTS:::HEADER-END::
+::ARG::src::ARG-END::     the source (required)
+::ARG::dest::ARG-END::    the destination (required)
+`
+		subject := NewCommand("copy", false)
+		subject.Description = "Copy things."
+		subject.RunSyntax = "<src> <dest>"
+		subject.Args = []*Arg{
+			{Name: "src", Description: "the source", Required: true},
+			{Name: "dest", Description: "the destination", Required: true},
+		}
+
+		got := DefaultCommandUsageMarkedFunc(subject)
+		if got != want {
+			t.Errorf("want: %s got: %s", want, got)
+		}
+	})
+}
+
+func TestArgsCountError_Error(t *testing.T) {
+	testCases := []struct {
+		name    string
+		subject *ArgsCountError
+		want    string
+	}{
+		{name: "exact", subject: &ArgsCountError{Path: "magic copy", Min: 2, Max: 2, Got: 3}, want: `"magic copy" expects 2 arguments, got 3`},
+		{name: "exact one", subject: &ArgsCountError{Path: "magic copy", Min: 1, Max: 1, Got: 0}, want: `"magic copy" expects 1 argument, got 0`},
+		{name: "none", subject: &ArgsCountError{Path: "magic copy", Min: 0, Max: 0, Got: 1}, want: `"magic copy" expects 0 arguments, got 1`},
+		{name: "min", subject: &ArgsCountError{Path: "magic copy", Min: 1, Max: -1, Got: 0}, want: `"magic copy" expects at least 1 argument, got 0`},
+		{name: "max", subject: &ArgsCountError{Path: "magic copy", Min: 0, Max: 2, Got: 3}, want: `"magic copy" expects at most 2 arguments, got 3`},
+		{name: "range", subject: &ArgsCountError{Path: "magic copy", Min: 1, Max: 3, Got: 4}, want: `"magic copy" expects between 1 and 3 arguments, got 4`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.subject.Error()
+			if got != tc.want {
+				t.Errorf("want %s, got %s", tc.want, got)
+			}
+			if !errors.Is(tc.subject, ErrArgsCount) {
+				t.Errorf("want %s, got %s", ErrArgsCount, tc.subject.Unwrap())
+			}
+		})
+	}
+}
+
+func Test_argsValidators(t *testing.T) {
+	testCases := []struct {
+		name     string
+		validate ArgsFunc
+		args     []string
+		wantErr  bool
+	}{
+		{name: "no args - valid", validate: NoArgs, args: []string{}},
+		{name: "no args - invalid", validate: NoArgs, args: []string{"one"}, wantErr: true},
+		{name: "exact args - valid", validate: ExactArgs(2), args: []string{"one", "two"}},
+		{name: "exact args - too few", validate: ExactArgs(2), args: []string{"one"}, wantErr: true},
+		{name: "exact args - too many", validate: ExactArgs(2), args: []string{"one", "two", "three"}, wantErr: true},
+		{name: "min args - valid", validate: MinArgs(1), args: []string{"one", "two"}},
+		{name: "min args - invalid", validate: MinArgs(1), args: []string{}, wantErr: true},
+		{name: "max args - valid", validate: MaxArgs(1), args: []string{}},
+		{name: "max args - invalid", validate: MaxArgs(1), args: []string{"one", "two"}, wantErr: true},
+		{name: "range args - valid", validate: RangeArgs(1, 2), args: []string{"one"}},
+		{name: "range args - invalid", validate: RangeArgs(1, 2), args: []string{"one", "two", "three"}, wantErr: true},
+		{name: "composed - valid", validate: ComposeArgs(MinArgs(1), nil, MaxArgs(2)), args: []string{"one", "two"}},
+		{name: "composed - invalid", validate: ComposeArgs(MinArgs(1), MaxArgs(2)), args: []string{"one", "two", "three"}, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			subject := &Command{Name: "copy"}
+			got := tc.validate(subject, tc.args)
+			if tc.wantErr && !errors.Is(got, ErrArgsCount) {
+				t.Errorf("want %s, got %v", ErrArgsCount, got)
+			}
+			if !tc.wantErr && got != nil {
+				t.Errorf("want nil, got %s", got)
+			}
+		})
+	}
+}
+
+func Test_DefaultCommandRunner_validateArgs(t *testing.T) {
+	t.Run("validate args are validated after flags and before piped in and check", func(t *testing.T) {
+		want := &ArgsCountError{Path: "magic copy", Min: 2, Max: 2, Got: 1}
+		lamp := NewLamp("magic", "0.0.0", true)
+		subject := NewCommand("copy", true)
+		subject.Flags.Bool("f", false, "force")
+		subject.ValidateArgs = ExactArgs(2)
+		subject.PipedIn = func(command *Command) (error, bool) {
+			t.Error("unexpected piped in")
+			return nil, false
+		}
+		subject.Check = func(command *Command) error {
+			t.Error("unexpected check")
+			return nil
+		}
+		subject.Run = func(command *Command) error {
+			t.Error("unexpected run")
+			return nil
+		}
+		lamp.RootCommand.SubCommands = []*Command{subject}
+
+		_, got := lamp.ExecuteWith([]string{"magic", "copy", "-f", "one"})
+		var countErr *ArgsCountError
+		if !errors.As(got, &countErr) {
+			t.Fatalf("want *ArgsCountError, got %T", got)
+		}
+		if !reflect.DeepEqual(countErr, want) {
+			t.Errorf("want %v, got %v", want, countErr)
+		}
+	})
+}
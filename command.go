@@ -1,6 +1,7 @@
 package genie
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"io"
@@ -33,52 +34,99 @@ func (n *NoopWriter) Write([]byte) (int, error) {
 
 // Command represents a command or subcommand of the interface.
 type Command struct {
-	Name           string
-	Aliases        []string
-	RunSyntax      string
-	Description    string
-	ArgInfo        string
-	ExtraInfo      string
-	Flags          *flag.FlagSet
-	SubCommands    []*Command
-	Out            io.Writer
-	Err            io.Writer
-	PipedIn        PipedInFunc
-	Check          CheckFunc
-	Run            RunFunc
-	Usage          UsageFunc
-	MergeFlagUsage bool
-	SilenceFlags   bool
-	Secret         bool
-	root           bool   //this is set at execution time
-	path           string //this is set at execution time
-	depth          int    //this is set at execution time
-	secretFlags    []string
+	Name              string
+	Aliases           []string
+	RunSyntax         string
+	Description       string
+	ArgInfo           string
+	ExtraInfo         string
+	Args              []*Arg   //optional positional argument specification, validated and bound before Check
+	ValidateArgs      ArgsFunc //optional positional argument validation, called before Check
+	Flags             *flag.FlagSet
+	PersistentFlags   *flag.FlagSet //flags accepted by this command and all of its subcommands
+	SubCommands       []*Command
+	In                io.Reader //the command's input, os.Stdin is used if nil
+	Out               io.Writer
+	Err               io.Writer
+	Piped             PipedDetector //decides if In is piped so PipedIn should be called, DefaultPipedDetector if nil
+	PipedIn           PipedInFunc
+	Check             CheckFunc
+	PersistentPreRun  RunFunc //called before Run of this command and all of its subcommands, from the root down
+	PreRun            RunFunc //called before Run, after any PersistentPreRun
+	Run               RunFunc
+	FanOut            *FanOut      //if set and input is piped, FanOut.Each is called per piped line instead of Run
+	PostRun           PostRunFunc  //called after Run even if it failed, before any PersistentPostRun
+	PersistentPostRun PostRunFunc  //called after Run of this command and all of its subcommands, from the command up
+	Middleware        []Middleware //wraps the execution of this command and all of its subcommands
+	RecoverPanics     bool         //if true a panic during execution is returned as a PanicError
+	Usage             UsageFunc
+	FlagSyntax        FlagSyntax //the syntax used to parse flags, the Lamp's syntax is used if not set
+	MergeFlagUsage    bool
+	SilenceFlags      bool
+	Secret            bool
+	root              bool     //this is set at execution time
+	path              string   //this is set at execution time
+	depth             int      //this is set at execution time
+	parent            *Command //this is set at execution time
+	secretFlags       []string
+	globalFlags       map[string]bool       //names of persistent flags merged into Flags at execution time
+	ctx               context.Context       //this is set at execution time
+	rawArgs           []string              //this is set at execution time
+	lamp              *Lamp                 //this is set at execution time
+	envBindings       map[string]string     //flag names to the environment variables they're bound to
+	flagOrigins       map[string]FlagOrigin //this is set at execution time
+	noValues          map[string]string     //values used for flags provided without one using FlagSyntaxGNU
+	shorthands        map[string]string     //shorthand flag names to the names of the flags they're for
+	requiredFlags     []string
 }
 
 // NewCommand returns a Command with sensible defaults.
 func NewCommand(name string, silenceFlags bool) *Command {
 	c := &Command{
 		Name:         name,
+		In:           os.Stdin,
 		Out:          os.Stdout,
 		Err:          os.Stderr,
 		Usage:        DefaultCommandUsageFunc,
 		SilenceFlags: silenceFlags,
 	}
 
-	if silenceFlags {
+	c.Flags = c.newFlagSet()
+	c.PersistentFlags = c.newFlagSet()
+
+	return c
+}
+
+func (c *Command) newFlagSet() *flag.FlagSet {
+	if c.SilenceFlags {
 		//this allows for flag parsing errors to continue through to caller to handle what/if anything is output to user
-		c.Flags = flag.NewFlagSet(name, flag.ContinueOnError)
-		c.Flags.SetOutput(&NoopWriter{})
-		c.Flags.Usage = NoopUsage
-	} else {
-		c.Flags = flag.NewFlagSet(name, flag.ExitOnError)
-		c.Flags.Usage = func() {
-			_, _ = fmt.Fprint(c.Err, DefaultCommandUsageFunc(c))
-		}
+		fs := flag.NewFlagSet(c.Name, flag.ContinueOnError)
+		fs.SetOutput(&NoopWriter{})
+		fs.Usage = NoopUsage
+		return fs
 	}
 
-	return c
+	fs := flag.NewFlagSet(c.Name, flag.ExitOnError)
+	fs.Usage = func() {
+		_, _ = fmt.Fprint(c.Err, DefaultCommandUsageFunc(c))
+	}
+	return fs
+}
+
+// SetIn sets the command's input reader, and all of it's subcommand's as well.
+func (c *Command) SetIn(i io.Reader) {
+	c.In = i
+	for _, sc := range c.SubCommands {
+		sc.SetIn(i)
+	}
+}
+
+// SetPiped sets the command's piped detector, and all of it's subcommand's as well.
+func (c *Command) SetPiped(detector PipedDetector) {
+	c.Piped = detector
+	for _, sc := range c.SubCommands {
+		sc.SetPiped(detector)
+	}
 }
 
 // SetOut sets the command's output writer, and all of it's subcommand's as well.
@@ -96,21 +144,47 @@ func (c *Command) SetErr(e io.Writer) {
 	if c.Flags != nil && !c.SilenceFlags {
 		c.Flags.SetOutput(e)
 	}
+	if c.PersistentFlags != nil && !c.SilenceFlags {
+		c.PersistentFlags.SetOutput(e)
+	}
 	for _, sc := range c.SubCommands {
 		sc.SetErr(e)
 	}
 }
 
+// Context returns the context the command is executing with. If the command was not executed with a context, or is
+// not executing, context.Background is returned so the result is always safe to use.
+func (c *Command) Context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+
+	return c.ctx
+}
+
+// SetContext sets the context the command will execute with. The Lamp provided execution methods will set this for you.
+func (c *Command) SetContext(ctx context.Context) {
+	c.ctx = ctx
+}
+
 // FlagsAndArgs returns a string representation of any flags and arguments provided to the command.
-// If using the Lamp provided execution methods, this method will be ready to use in Check and Run.
+// If using the Lamp provided execution methods, this method will be ready to use in Check and Run. Flags set from the
+// environment or a config file weren't provided, so they're not included.
 func (c *Command) FlagsAndArgs() string {
 	if c.Flags == nil {
 		return ""
 	}
 
 	sb := strings.Builder{}
+	seen := make(map[string]bool)
 	c.Flags.Visit(func(f *flag.Flag) {
-		sb.WriteString(fmt.Sprintf("%s %s ", f.Name, f.Value.String()))
+		//a flag and its shorthand are reported once, by the flag's name
+		name := c.canonicalFlag(f.Name)
+		if seen[name] || !c.FlagWasProvided(name) {
+			return
+		}
+		seen[name] = true
+		sb.WriteString(fmt.Sprintf("%s %s ", name, f.Value.String()))
 	})
 	if len(c.Flags.Args()) > 0 {
 		for _, arg := range c.Flags.Args() {
@@ -122,15 +196,26 @@ func (c *Command) FlagsAndArgs() string {
 	return s
 }
 
-// FlagWasProvided returns true if the flag was actually provided at execution time.
+// RawArgs returns the arguments provided after the "--" terminator, these are never parsed as flags or used to find
+// commands. Returns nil if no terminator was provided, or the command hasn't been executed.
+func (c *Command) RawArgs() []string {
+	return c.rawArgs
+}
+
+// FlagWasProvided returns true if the flag was actually provided on the command line at execution time.
 func (c *Command) FlagWasProvided(name string) bool {
 	if c.Flags == nil {
 		return false
 	}
 
+	name = c.canonicalFlag(name)
+	if c.flagOrigins != nil {
+		return c.flagOrigins[name].Source == SourceArgs
+	}
+
 	set := false
 	c.Flags.Visit(func(f *flag.Flag) {
-		if name == f.Name {
+		if name == c.canonicalFlag(f.Name) {
 			set = true
 		}
 	})
@@ -152,6 +237,15 @@ func (c *Command) ShowUsage() string {
 	return DefaultCommandUsageFunc(c)
 }
 
+// pathOrName returns the path to this command, or its name if the path has not been set.
+func (c *Command) pathOrName() string {
+	if c.path == "" {
+		return c.Name
+	}
+
+	return c.path
+}
+
 // Path returns the path to this command from the "anchor" command.
 // The path will be blank until AnchorPaths is called here or on a parent command, or when command interface is executed.
 func (c *Command) Path() string {
@@ -162,6 +256,7 @@ func (c *Command) Path() string {
 func (c *Command) AnchorPaths() {
 	c.path = c.Name
 	for _, sc := range c.SubCommands {
+		sc.parent = c
 		sc.adjustPath(c.path, c.depth+1)
 	}
 }
@@ -170,29 +265,92 @@ func (c *Command) adjustPath(path string, depth int) {
 	c.path = fmt.Sprintf("%s %s", path, c.Name)
 	c.depth = depth
 	for _, sc := range c.SubCommands {
+		sc.parent = c
 		sc.adjustPath(c.path, c.depth+1)
 	}
 }
 
+// inheritedFlags returns the persistent flags of this command and its parents that apply to this command, nearest
+// command first. Flags defined locally on the command take precedence over persistent flags of the same name.
+func (c *Command) inheritedFlags() []*flag.Flag {
+	var flags []*flag.Flag
+	seen := make(map[string]bool)
+	for cmd := c; cmd != nil; cmd = cmd.parent {
+		if cmd.PersistentFlags == nil {
+			continue
+		}
+
+		cmd.PersistentFlags.VisitAll(func(f *flag.Flag) {
+			if seen[f.Name] || cmd.flagIsSecret(f.Name) || c.flagIsSecret(f.Name) || cmd.isShorthand(f.Name) {
+				return
+			}
+			if c.Flags != nil && c.Flags.Lookup(f.Name) != nil && !c.globalFlags[f.Name] {
+				return
+			}
+
+			seen[f.Name] = true
+			flags = append(flags, f)
+		})
+	}
+
+	return flags
+}
+
+// mergePersistentFlags adds any persistent flags from this command and its parents to Flags, so they can be parsed
+// alongside the local flags.
+func (c *Command) mergePersistentFlags() {
+	for cmd := c; cmd != nil; cmd = cmd.parent {
+		if cmd.PersistentFlags == nil {
+			continue
+		}
+
+		cmd.PersistentFlags.VisitAll(func(f *flag.Flag) {
+			if c.Flags == nil {
+				c.Flags = c.newFlagSet()
+			}
+			if c.Flags.Lookup(f.Name) != nil {
+				return
+			}
+
+			c.Flags.Var(f.Value, f.Name, f.Usage)
+			c.Flags.Lookup(f.Name).DefValue = f.DefValue
+			if c.globalFlags == nil {
+				c.globalFlags = make(map[string]bool)
+			}
+			c.globalFlags[f.Name] = true
+		})
+	}
+}
+
 func (c *Command) findSubCommand(name string) (*Command, bool) {
 	for _, command := range c.SubCommands {
-		if name == command.Name {
+		if command.hasName(name) {
 			return command, true
 		}
+	}
 
-		for _, alias := range command.Aliases {
-			if name == alias {
-				return command, true
-			}
+	return nil, false
+}
+
+// hasName returns true if name is the command's name or one of its aliases.
+func (c *Command) hasName(name string) bool {
+	if name == c.Name {
+		return true
+	}
+
+	for _, alias := range c.Aliases {
+		if name == alias {
+			return true
 		}
 	}
 
-	return nil, false
+	return false
 }
 
 func (c *Command) flagIsSecret(name string) bool {
+	name = c.canonicalFlag(name)
 	for _, f := range c.secretFlags {
-		if f == name {
+		if c.canonicalFlag(f) == name {
 			return true
 		}
 	}
@@ -204,7 +362,13 @@ func (c *Command) run(args []string) error { //only flags/args: -flag value -fla
 	return DefaultCommandRunner(c, args)
 }
 
-var DefaultCommandRunner = func(command *Command, args []string) error { //only flags/args: -flag value -flag2 value2 arg1 arg2
+func (c *Command) runContext(ctx context.Context, args []string) error {
+	c.ctx = ctx
+	return c.run(args)
+}
+
+var DefaultCommandRunner = func(command *Command, args []string) (err error) { //only flags/args: -flag value -flag2 value2 arg1 arg2
+	command.addParallelFlag()
 	if ContainsFlag("help", args) {
 		if command.Out != nil {
 			_, _ = fmt.Fprint(command.Out, command.ShowUsage())
@@ -213,44 +377,131 @@ var DefaultCommandRunner = func(command *Command, args []string) error { //only
 		return flag.ErrHelp
 	}
 
-	if command.Run == nil {
-		return ErrCommandNotRunnable
+	if command.Run == nil && command.FanOut == nil {
+		return &NotRunnableError{Path: command.pathOrName()}
+	}
+
+	//cleanups registered by the command are run once it completes, if executed by a Lamp they may run sooner on signal
+	stack, ok := cleanupsFromContext(command.Context())
+	if !ok {
+		stack = &cleanups{}
+		command.ctx = context.WithValue(command.Context(), cleanupsKey{}, stack)
+	}
+	defer stack.run()
+
+	//deferred after the cleanups so a panic is recovered before they are run
+	if command.recoversPanics() {
+		defer command.recoverPanic(&err)
+	}
+
+	command.mergePersistentFlags()
+	if command.Flags != nil && command.flagSyntax(command.lamp) == FlagSyntaxGNU {
+		args = command.translateGNU(args)
 	}
 
 	if command.Flags != nil {
 		err := command.Flags.Parse(args)
 		//Technically we'd not get here if flagset error handling is set to flag.ExitOnError, or flag.PanicOnError,
 		//but for folks who use ContinueOnError we can return the error for custom handling if desired, so we pack it
-		//in a geenee.Error for easier identification
+		//in a geenee.FlagError for easier identification
 		if err != nil {
-			return Error(err.Error())
+			return newFlagError(command, args, err)
+		}
+	}
+	command.rawArgs = terminatedArgs(command.Flags, args)
+
+	//environment variables, then the config file, only apply to flags not already set, so the command line always wins
+	command.recordArgsOrigins()
+	if err := command.applyEnv(); err != nil {
+		return err
+	}
+
+	if err := command.applyConfig(); err != nil {
+		return err
+	}
+
+	//printing the effective config replaces running the command, so required arguments don't get in the way
+	if format := command.printConfigFormat(); format != "" {
+		if command.Out == nil {
+			return nil
+		}
+		return command.WriteEffectiveConfig(command.Out, format)
+	}
+
+	if err := command.checkRequiredFlags(); err != nil {
+		return err
+	}
+
+	positional := command.positionalArgs(args)
+	if command.ValidateArgs != nil {
+		if err := command.ValidateArgs(command, positional); err != nil {
+			return err
 		}
 	}
 
-	fileInfo, err := os.Stdin.Stat()
+	if err := command.bindArgs(positional); err != nil {
+		return err
+	}
+
+	//the context may have been cancelled while we were parsing, no reason to go any further
+	if err := command.Context().Err(); err != nil {
+		return err
+	}
+
 	skipCheck := false
-	if err == nil {
-		if command.PipedIn != nil && ((fileInfo.Mode() & os.ModeCharDevice) == 0) {
-			// we're receiving input (stdin) via pipe
-			err, skipCheck = command.PipedIn(command)
-			if err != nil {
-				return err
-			}
+	fanOut := command.fansOut()
+	if command.PipedIn != nil && !fanOut && command.IsPiped() {
+		// we're receiving input (stdin) via pipe
+		err, skipCheck = command.PipedIn(command)
+		if err != nil {
+			return err
 		}
 	}
 
 	if command.Check != nil && !skipCheck {
+		if err := command.Context().Err(); err != nil {
+			return err
+		}
+
+		//genie's own sentinels are given the command path, anything else is the caller's and returned as is
 		err = command.Check(command)
+		if sentinel, ok := err.(Error); ok {
+			return &ValidationError{Path: command.pathOrName(), Err: sentinel}
+		}
 		if err != nil {
 			return err
 		}
 	}
 
-	return command.Run(command)
+	if err := command.Context().Err(); err != nil {
+		return err
+	}
+
+	//a command that only fans out can't run when nothing was piped
+	if command.Run == nil && !fanOut {
+		return &NotRunnableError{Path: command.pathOrName()}
+	}
+
+	//middleware wraps the hooks and Run, so it sees the final result of the command's execution
+	run := command.wrap(func(command *Command) error {
+		if err := command.runPreHooks(); err != nil {
+			return err
+		}
+
+		if fanOut {
+			return command.runPostHooks(command.runFanOut())
+		}
+
+		return command.runPostHooks(command.Run(command))
+	})
+
+	return run(command)
 }
 
+// ContainsFlag returns true if the flag is found in flags, either as -flag or --flag. Anything after the "--"
+// terminator is not considered a flag.
 func ContainsFlag(flag string, flags []string) bool {
-	for _, f := range flags {
+	for _, f := range beforeTerminator(flags) {
 		if f == "-"+flag || f == "--"+flag {
 			return true
 		}
@@ -258,3 +509,42 @@ func ContainsFlag(flag string, flags []string) bool {
 
 	return false
 }
+
+// beforeTerminator returns the arguments before the "--" terminator, or all arguments if there is no terminator.
+func beforeTerminator(args []string) []string {
+	for i, arg := range args {
+		if arg == "--" {
+			return args[:i]
+		}
+	}
+
+	return args
+}
+
+// terminatedArgs returns the arguments after the "--" terminator. If flags were parsed the terminator is only looked
+// for where parsing stopped, so a "--" provided as a flag value is not mistaken for the terminator.
+func terminatedArgs(flags *flag.FlagSet, args []string) []string {
+	remaining := args
+	if flags != nil && flags.Parsed() {
+		remaining = flags.Args()
+		consumed := len(args) - len(remaining)
+		for i := 0; i < consumed; i++ {
+			if args[i] == "--" {
+				return remaining
+			}
+
+			name, hasValue := flagArgName(args[i])
+			if f := flags.Lookup(name); f != nil && !hasValue && !isBoolFlag(f) {
+				i++ //skip the flag value
+			}
+		}
+	}
+
+	for i, arg := range remaining {
+		if arg == "--" {
+			return remaining[i+1:]
+		}
+	}
+
+	return nil
+}
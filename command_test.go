@@ -3,6 +3,7 @@ package genie
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"errors"
 	"flag"
 	"fmt"
@@ -120,12 +121,37 @@ func Test_NewCommand(t *testing.T) {
 		if got.Flags.ErrorHandling() != flag.ContinueOnError {
 			t.Errorf("want %d, got %d", flag.ContinueOnError, got.Flags.ErrorHandling())
 		}
+		if got.PersistentFlags == nil {
+			t.Fatal("want persistent flags, got nil")
+		}
+		if got.PersistentFlags.ErrorHandling() != flag.ContinueOnError {
+			t.Errorf("want %d, got %d", flag.ContinueOnError, got.PersistentFlags.ErrorHandling())
+		}
 		if reflect.TypeOf(got.Flags.Output()) != reflect.TypeOf(&NoopWriter{}) {
 			t.Errorf("want %T, got %T", &NoopWriter{}, got.Flags.Output())
 		}
 	})
 }
 
+func TestCommand_Context(t *testing.T) {
+	t.Run("validate background context when not set", func(t *testing.T) {
+		subject := &Command{Name: "test"}
+		if subject.Context() != context.Background() {
+			t.Errorf("want %v, got %v", context.Background(), subject.Context())
+		}
+	})
+
+	t.Run("validate context when set", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		subject := &Command{Name: "test"}
+		subject.SetContext(ctx)
+		if subject.Context() != ctx {
+			t.Errorf("want %v, got %v", ctx, subject.Context())
+		}
+	})
+}
+
 func TestCommand_FlagsAndArgs(t *testing.T) {
 	t.Run("validate flags and args are printed as expected", func(t *testing.T) {
 		want := "d true flag 100 heyo playo argone argtwo" //flags will be sorted, args will not!
@@ -257,6 +283,44 @@ func TestCommand_adjustPath(t *testing.T) {
 	})
 }
 
+func TestCommand_RawArgs(t *testing.T) {
+	testCases := []struct {
+		name  string
+		flags bool
+		args  []string
+		want  []string
+	}{
+		{name: "no terminator", flags: true, args: []string{"-flag", "value", "arg"}, want: nil},
+		{name: "terminator after flags", flags: true, args: []string{"-flag", "value", "--", "grep", "--help"}, want: []string{"grep", "--help"}},
+		{name: "terminator after args", flags: true, args: []string{"arg", "--", "-v"}, want: []string{"-v"}},
+		{name: "terminator as flag value", flags: true, args: []string{"-flag", "--", "arg"}, want: nil},
+		{name: "terminator without flags", flags: false, args: []string{"-flag", "--", "-v"}, want: []string{"-v"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			subject := &Command{
+				Name: "command",
+				Run: func(command *Command) error {
+					return nil
+				},
+			}
+			if tc.flags {
+				subject.Flags = flag.NewFlagSet("command", flag.ContinueOnError)
+				subject.Flags.String("flag", "", "testing flags")
+			}
+
+			err := subject.run(tc.args)
+			if err != nil {
+				t.Errorf("[err] want nil, got %s", err)
+			}
+			if !reflect.DeepEqual(subject.RawArgs(), tc.want) {
+				t.Errorf("want %v, got %v", tc.want, subject.RawArgs())
+			}
+		})
+	}
+}
+
 func TestCommand_FlagWasProvided(t *testing.T) {
 	t.Run("validate flag is found", func(t *testing.T) {
 		want := "I was provided."
@@ -327,6 +391,87 @@ func TestCommand_FlagWasProvided(t *testing.T) {
 	})
 }
 
+func TestCommand_PersistentFlags(t *testing.T) {
+	t.Run("validate persistent flags are parsed by subcommands", func(t *testing.T) {
+		config := ""
+		verbose := false
+		test := ""
+		root := NewCommand("lamp", true)
+		root.PersistentFlags.StringVar(&config, "config", "", "the config file")
+		sub := NewCommand("wish", true)
+		sub.PersistentFlags.BoolVar(&verbose, "verbose", false, "verbose output")
+		subsub := NewCommand("big", true)
+		subsub.Flags.StringVar(&test, "test", "", "the test flag")
+		subsub.Run = func(command *Command) error {
+			if !command.FlagWasProvided("config") {
+				t.Error("want config provided, got false")
+			}
+			if !command.FlagWasProvided("verbose") {
+				t.Error("want verbose provided, got false")
+			}
+			return nil
+		}
+		sub.SubCommands = []*Command{subsub}
+		root.SubCommands = []*Command{sub}
+		root.AnchorPaths()
+
+		err := subsub.run([]string{"--verbose", "--config", "genie.json", "-test", "heyo"})
+		if err != nil {
+			t.Errorf("[err] want nil, got %s", err)
+		}
+		if config != "genie.json" {
+			t.Errorf("want genie.json, got %s", config)
+		}
+		if !verbose {
+			t.Error("want true, got false")
+		}
+		if test != "heyo" {
+			t.Errorf("want heyo, got %s", test)
+		}
+	})
+
+	t.Run("validate persistent flags are parsed by the declaring command", func(t *testing.T) {
+		config := ""
+		subject := &Command{
+			Name:            "lamp",
+			PersistentFlags: flag.NewFlagSet("lamp", flag.ContinueOnError),
+			Run: func(command *Command) error {
+				return nil
+			},
+		}
+		subject.PersistentFlags.StringVar(&config, "config", "", "the config file")
+
+		err := subject.run([]string{"--config", "genie.json"})
+		if err != nil {
+			t.Errorf("[err] want nil, got %s", err)
+		}
+		if config != "genie.json" {
+			t.Errorf("want genie.json, got %s", config)
+		}
+		if !subject.FlagWasProvided("config") {
+			t.Error("want true, got false")
+		}
+	})
+
+	t.Run("validate local flags shadow persistent flags", func(t *testing.T) {
+		root := NewCommand("lamp", true)
+		root.PersistentFlags.String("config", "", "the config file")
+		root.PersistentFlags.String("verbose", "", "the verbose level")
+		subject := NewCommand("wish", true)
+		subject.Flags.String("config", "", "a different config")
+		root.SubCommands = []*Command{subject}
+		root.AnchorPaths()
+
+		got := subject.inheritedFlags()
+		if len(got) != 1 {
+			t.Fatalf("want 1, got %d", len(got))
+		}
+		if got[0].Name != "verbose" {
+			t.Errorf("want verbose, got %s", got[0].Name)
+		}
+	})
+}
+
 func TestCommand_findSubCommand(t *testing.T) {
 	t.Run("validate subcommand is found", func(t *testing.T) {
 		want := &Command{Name: "subcommand"}
@@ -691,7 +836,7 @@ func TestCommand_run(t *testing.T) {
 		}
 
 		got := subject.run([]string{"-flag", "value"})
-		if got != want {
+		if !errors.Is(got, want) {
 			t.Errorf("want %s, got %s", want, got)
 		}
 	})
@@ -709,15 +854,15 @@ func TestCommand_run(t *testing.T) {
 	})
 
 	t.Run("validate command run returns flag errors when silence flags is true", func(t *testing.T) {
-		want := errors.New("flag provided but not defined: -flag")
+		want := errors.New("unknown flag --flag")
 		subject := NewCommand("silenced", true)
 		subject.Run = func(command *Command) error {
 			return nil
 		}
 		got := subject.run([]string{"-flag", "value"})
-		_, ok := got.(Error)
+		_, ok := got.(*FlagError)
 		if !ok {
-			t.Errorf("want geenee.Error, got %T", got)
+			t.Errorf("want *geenee.FlagError, got %T", got)
 		}
 		if got.Error() != want.Error() {
 			t.Errorf("want %s, got %s", want.Error(), got.Error())
@@ -784,6 +929,54 @@ func Test_DefaultCommandRunner(t *testing.T) {
 	})
 }
 
+func Test_DefaultCommandRunner_context(t *testing.T) {
+	t.Run("validate check is not called when context cancelled", func(t *testing.T) {
+		want := context.Canceled
+		ctx, cancel := context.WithCancel(context.Background())
+		subject := &Command{
+			Name:  "command",
+			Flags: flag.NewFlagSet("command", flag.ContinueOnError),
+			Check: func(command *Command) error {
+				t.Error("unexpected check")
+				return nil
+			},
+			Run: func(command *Command) error {
+				t.Error("unexpected run")
+				return nil
+			},
+		}
+		subject.SetContext(ctx)
+		cancel()
+
+		got := DefaultCommandRunner(subject, []string{})
+		if got != want {
+			t.Errorf("want %s, got %s", want, got)
+		}
+	})
+
+	t.Run("validate run is not called when check cancels context", func(t *testing.T) {
+		want := context.Canceled
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		subject := &Command{
+			Name: "command",
+			Check: func(command *Command) error {
+				cancel()
+				return nil
+			},
+			Run: func(command *Command) error {
+				t.Error("unexpected run")
+				return nil
+			},
+		}
+
+		got := subject.runContext(ctx, []string{})
+		if got != want {
+			t.Errorf("want %s, got %s", want, got)
+		}
+	})
+}
+
 func Test_ContainsFlag(t *testing.T) {
 	t.Run("validate --help is found", func(t *testing.T) {
 		args := []string{"interface", "command", "subcommand", "-flag", "value", "--help", "-d"}
@@ -801,6 +994,14 @@ func Test_ContainsFlag(t *testing.T) {
 		}
 	})
 
+	t.Run("validate help is not found after terminator", func(t *testing.T) {
+		args := []string{"interface", "command", "-flag", "value", "--", "grep", "--help"}
+		hasFlag := ContainsFlag("help", args)
+		if hasFlag {
+			t.Errorf("want false, got %t", hasFlag)
+		}
+	})
+
 	t.Run("validate help is not found", func(t *testing.T) {
 		args := []string{"interface", "command", "subcommand", "-flag", "value", "-d"}
 		hasFlag := ContainsFlag("help", args)
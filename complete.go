@@ -1,19 +1,29 @@
 package genie
 
 import (
+	"flag"
 	"fmt"
+	"sort"
 	"strings"
 )
 
-//CompletionReply provides very basic completion support for CLIs using geenee. Currently, only subcommand completion is
-//supported. Flag and argument completion is not supported.
+// CompletionReply provides very basic completion support for CLIs using geenee. Currently, only subcommand and flag
+// completion is supported. Argument completion is not supported.
 func (l *Lamp) CompletionReply(line string) string {
 	reply := ""
-	if l.RootCommand == nil || len(l.RootCommand.SubCommands) == 0 {
+	if l.RootCommand == nil {
 		return reply
 	}
 
 	path := strings.Split(line, " ")
+	if len(path) > 1 && strings.HasPrefix(path[len(path)-1], "-") {
+		return l.completeFlags(path[1:len(path)-1], path[len(path)-1])
+	}
+
+	if len(l.RootCommand.SubCommands) == 0 {
+		return reply
+	}
+
 	if len(path) == 1 {
 		if path[0] == "" {
 			return reply
@@ -67,6 +77,10 @@ func (l *Lamp) CompletionReply(line string) string {
 	pos += 1
 
 	if len(path)-1 == pos { //this means the completion request is just for the next set of subcommands
+		//unless the last part only matched by prefix, in which case we complete it to the full command name
+		if !cmd.hasName(path[pos]) {
+			return cmd.Name
+		}
 		return reply
 	} else { //we have values after the command that was found, let's take next path part and narrow down subcommands
 		i := 0
@@ -89,9 +103,54 @@ func (l *Lamp) CompletionReply(line string) string {
 	return reply
 }
 
-//GenerateBashCompletion will return a bash script that can be sourced to provide a hook into your completion logic.
-//If you use this with your CLI you'll need to reply to the compreply with the appropriate values to show the user.
-//You can use the simple completion support provided by the CompletionReply function or roll your own.
+// completeFlags returns the flags of the command found in path that start with prefix. A flag and its shorthand are
+// completed as one, by the flag's name.
+func (l *Lamp) completeFlags(path []string, prefix string) string {
+	var commandPath []string
+	for _, part := range path {
+		if part != "" && !strings.HasPrefix(part, "-") {
+			commandPath = append(commandPath, part)
+		}
+	}
+
+	l.RootCommand.root = true
+	l.RootCommand.AnchorPaths()
+	cmd := l.RootCommand
+	if found, ok, _ := l.searchPathForCommand(commandPath, true); ok {
+		cmd = found
+	}
+
+	var names []string
+	if cmd.Flags != nil {
+		cmd.Flags.VisitAll(func(f *flag.Flag) {
+			if !cmd.flagIsSecret(f.Name) && !cmd.globalFlags[f.Name] && !cmd.isShorthand(f.Name) {
+				names = append(names, f.Name)
+			}
+		})
+	}
+	for _, f := range cmd.inheritedFlags() {
+		names = append(names, f.Name)
+	}
+	if cmd == l.RootCommand {
+		names = append(names, "version")
+	}
+	names = append(names, "help")
+	sort.Strings(names)
+
+	var reply []string
+	for _, name := range names {
+		short := cmd.shorthandOf(name)
+		if strings.HasPrefix(dashed(name), prefix) || (short != "" && strings.HasPrefix(dashed(short), prefix)) {
+			reply = append(reply, dashed(name))
+		}
+	}
+
+	return strings.Join(reply, " ")
+}
+
+// GenerateBashCompletion will return a bash script that can be sourced to provide a hook into your completion logic.
+// If you use this with your CLI you'll need to reply to the compreply with the appropriate values to show the user.
+// You can use the simple completion support provided by the CompletionReply function or roll your own.
 func GenerateBashCompletion(cli *Lamp) string {
 	complete := `#!/bin/bash
 function _%s () {
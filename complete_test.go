@@ -104,6 +104,24 @@ func TestCommandInterface_CompletionReply(t *testing.T) {
 			subject: &Lamp{Name: "subject", RootCommand: &Command{Name: "subject", SubCommands: []*Command{&Command{Name: "heyo", SubCommands: []*Command{&Command{Name: "cool"}, &Command{Name: "bro"}, &Command{Name: "crazy"}}}, &Command{Name: "playo", SubCommands: []*Command{&Command{Name: "dang"}, &Command{Name: "it"}}}}}},
 			want:    "cool crazy",
 		},
+		{
+			name:    "commands on root returned - prefix matching",
+			line:    "subject he",
+			subject: &Lamp{Name: "subject", PrefixMatching: true, RootCommand: &Command{Name: "subject", SubCommands: []*Command{&Command{Name: "heyo"}, &Command{Name: "playo"}, &Command{Name: "hey", Secret: true}}}},
+			want:    "heyo",
+		},
+		{
+			name:    "subcommands returned - prefix matching partial path",
+			line:    "subject he c",
+			subject: &Lamp{Name: "subject", PrefixMatching: true, RootCommand: &Command{Name: "subject", SubCommands: []*Command{&Command{Name: "heyo", SubCommands: []*Command{&Command{Name: "cool"}, &Command{Name: "bro"}, &Command{Name: "crazy"}}}, &Command{Name: "playo", SubCommands: []*Command{&Command{Name: "dang"}, &Command{Name: "it"}}}}}},
+			want:    "cool crazy",
+		},
+		{
+			name:    "subcommands returned - prefix matching full path trailing space",
+			line:    "subject he ",
+			subject: &Lamp{Name: "subject", PrefixMatching: true, RootCommand: &Command{Name: "subject", SubCommands: []*Command{&Command{Name: "heyo", SubCommands: []*Command{&Command{Name: "cool"}, &Command{Name: "bro"}}}, &Command{Name: "playo", SubCommands: []*Command{&Command{Name: "dang"}, &Command{Name: "it"}}}}}},
+			want:    "cool bro",
+		},
 		{
 			name:    "subcommands returned - partial path not found",
 			line:    "subject heyo z",
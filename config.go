@@ -0,0 +1,354 @@
+package genie
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrConfigSyntax         = Error("invalid config syntax")
+	ErrConfigUnknownCommand = Error("unknown command in config")
+	ErrConfigUnknownKey     = Error("unknown flag in config")
+	ErrConfigInvalidValue   = Error("invalid flag value in config")
+)
+
+// ConfigError is returned when a config file can't be read, parsed, or applied to the command's flags.
+type ConfigError struct {
+	File   string //the config file
+	Line   int    //the line the error occurred on, 0 if not known
+	Path   string //path of the command the error applies to, if any
+	Key    string //the flag the error applies to, if any
+	Value  string //the value of the flag, if any
+	Reason string //why the config was invalid, if known
+	Err    error  //one of the ErrConfig errors, or the error reading the file
+}
+
+func (e *ConfigError) Error() string {
+	location := e.File
+	if e.Line > 0 {
+		location = fmt.Sprintf("%s:%d", e.File, e.Line)
+	}
+
+	reason := ""
+	if e.Reason != "" {
+		reason = ": " + e.Reason
+	}
+
+	switch e.Err {
+	case ErrConfigUnknownCommand:
+		return fmt.Sprintf("%s: unknown command %q", location, e.Path)
+	case ErrConfigUnknownKey:
+		return fmt.Sprintf("%s: unknown flag %s for %q", location, dashed(e.Key), e.Path)
+	case ErrConfigInvalidValue:
+		return fmt.Sprintf("%s: invalid value %q for flag %s of %q%s", location, e.Value, dashed(e.Key), e.Path, reason)
+	}
+
+	return fmt.Sprintf("%s: %s%s", location, e.Err, reason)
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// configValue is a single flag value from a config file.
+type configValue struct {
+	key    string
+	values []string //more than one value is set in order, for flags that accumulate
+	line   int
+}
+
+// config holds the flag values of a config file, by command path.
+type config struct {
+	file     string
+	sections map[string][]configValue
+	lines    map[string]int //line each section started on
+}
+
+// SetConfigFile sets the config file loaded when executing, and adds a persistent --config flag to the root command so
+// it can be overridden. A missing config file is ignored unless it was provided with --config.
+//
+// Config files hold flag values keyed by command path. JSON files (.json) are an object of paths to objects of flag
+// values, all other files use a simple INI/TOML subset:
+//
+//	verbose = true        # keys before any section are for the root command
+//	[magic wish]          # or ["magic wish"]
+//	count = 3
+//	name = "genie"
+func (l *Lamp) SetConfigFile(file string) {
+	l.ConfigFile = file
+	if l.RootCommand.PersistentFlags == nil {
+		l.RootCommand.PersistentFlags = l.RootCommand.newFlagSet()
+	}
+
+	if l.RootCommand.PersistentFlags.Lookup("config") == nil {
+		l.RootCommand.PersistentFlags.String("config", file, "config file to load")
+		l.configFlag = l.RootCommand.PersistentFlags.Lookup("config").Value
+	}
+}
+
+// configFile returns the config file the command should load, and true if it was explicitly provided. The --config
+// flag is only read if SetConfigFile added it, a --config flag defined by the interface is its own.
+func (c *Command) configFile() (string, bool) {
+	if c.lamp == nil || c.lamp.ConfigFile == "" {
+		return "", false
+	}
+
+	if c.Flags != nil && c.lamp.configFlag != nil {
+		if f := c.Flags.Lookup("config"); f != nil && f.Value == c.lamp.configFlag {
+			_, provided := c.flagOrigins["config"]
+			return f.Value.String(), provided
+		}
+	}
+
+	return c.lamp.ConfigFile, false
+}
+
+// applyConfig sets any flags not provided on the command line or environment from the config file.
+func (c *Command) applyConfig() error {
+	file, provided := c.configFile()
+	if file == "" {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && !provided {
+			return nil
+		}
+		return &ConfigError{File: file, Err: err}
+	}
+
+	cfg, err := parseConfig(file, data, c.lamp.Name)
+	if err != nil {
+		return err
+	}
+
+	if err := c.lamp.validateConfig(cfg); err != nil {
+		return err
+	}
+
+	//the executing command's section wins over the section of the command a persistent flag was declared on
+	values := make(map[string]configValue)
+	for cmd := c; cmd != nil; cmd = cmd.parent {
+		for _, v := range cfg.sections[cmd.pathOrName()] {
+			key := cmd.canonicalFlag(v.key)
+			if _, exists := values[key]; !exists && (cmd == c || cmd.declaresPersistent(key)) {
+				values[key] = v
+			}
+		}
+	}
+
+	if c.Flags == nil {
+		return nil
+	}
+
+	var setErr error
+	c.Flags.VisitAll(func(f *flag.Flag) {
+		v, ok := values[f.Name]
+		if _, set := c.flagOrigins[f.Name]; set || !ok || setErr != nil || c.isShorthand(f.Name) {
+			return
+		}
+
+		for _, value := range v.values {
+			if err := c.Flags.Set(f.Name, value); err != nil {
+				setErr = &ConfigError{File: file, Line: v.line, Path: c.pathOrName(), Key: f.Name, Value: value, Reason: err.Error(), Err: ErrConfigInvalidValue}
+				return
+			}
+		}
+		c.flagOrigins[f.Name] = FlagOrigin{Source: SourceConfig, Name: file}
+	})
+
+	return setErr
+}
+
+// declaresPersistent returns true if the named flag is one of the command's persistent flags.
+func (c *Command) declaresPersistent(name string) bool {
+	return c.PersistentFlags != nil && c.PersistentFlags.Lookup(name) != nil
+}
+
+// acceptsFlag returns true if the named flag can be set for the command, either locally or as a persistent flag.
+func (c *Command) acceptsFlag(name string) bool {
+	if c.Flags != nil && c.Flags.Lookup(name) != nil {
+		return true
+	}
+
+	for cmd := c; cmd != nil; cmd = cmd.parent {
+		if cmd.declaresPersistent(name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validateConfig checks every section of the config is a command, and every key is a flag of that command.
+func (l *Lamp) validateConfig(cfg *config) error {
+	commands := make(map[string]*Command)
+	l.TraverseCommands(func(command *Command) {
+		commands[command.pathOrName()] = command
+	})
+
+	for path, values := range cfg.sections {
+		command, ok := commands[path]
+		if !ok {
+			return &ConfigError{File: cfg.file, Line: cfg.lines[path], Path: path, Err: ErrConfigUnknownCommand}
+		}
+
+		for _, v := range values {
+			if !command.acceptsFlag(v.key) {
+				return &ConfigError{File: cfg.file, Line: v.line, Path: path, Key: v.key, Err: ErrConfigUnknownKey}
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseConfig parses the config file data, JSON if the file has a .json extension, otherwise the INI/TOML subset.
+// Values before any section belong to the root command.
+func parseConfig(file string, data []byte, root string) (*config, error) {
+	if strings.EqualFold(filepath.Ext(file), ".json") {
+		return parseJSONConfig(file, data)
+	}
+
+	return parseINIConfig(file, data, root)
+}
+
+func parseJSONConfig(file string, data []byte) (*config, error) {
+	var msg json.RawMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, &ConfigError{File: file, Reason: err.Error(), Err: ErrConfigSyntax}
+	}
+
+	//numbers are kept as written, so large integers aren't formatted as floats
+	var raw map[string]map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(msg))
+	decoder.UseNumber()
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, &ConfigError{File: file, Reason: err.Error(), Err: ErrConfigSyntax}
+	}
+
+	cfg := &config{file: file, sections: make(map[string][]configValue), lines: make(map[string]int)}
+	for path, values := range raw {
+		cfg.sections[path] = []configValue{}
+		for key, value := range values {
+			v := configValue{key: key}
+			items, isList := value.([]interface{})
+			if !isList {
+				items = []interface{}{value}
+			}
+
+			for _, item := range items {
+				switch item := item.(type) {
+				case json.Number:
+					v.values = append(v.values, item.String())
+				case string, bool:
+					v.values = append(v.values, fmt.Sprint(item))
+				default:
+					return nil, &ConfigError{File: file, Path: path, Key: key, Value: fmt.Sprint(item), Reason: "not a string, number, or boolean", Err: ErrConfigInvalidValue}
+				}
+			}
+			cfg.sections[path] = append(cfg.sections[path], v)
+		}
+	}
+
+	return cfg, nil
+}
+
+func parseINIConfig(file string, data []byte, root string) (*config, error) {
+	cfg := &config{file: file, sections: make(map[string][]configValue), lines: make(map[string]int)}
+	section := root
+	seen := make(map[string]int)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	n := 0
+	for scanner.Scan() {
+		n++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		if line[0] == '[' {
+			end := strings.LastIndex(line, "]")
+			if end < 0 {
+				return nil, &ConfigError{File: file, Line: n, Err: ErrConfigSyntax}
+			}
+
+			name, err := configString(strings.TrimSpace(line[1:end]))
+			if err != nil || name == "" {
+				return nil, &ConfigError{File: file, Line: n, Err: ErrConfigSyntax}
+			}
+
+			section = name
+			cfg.lines[section] = n
+			if _, exists := cfg.sections[section]; !exists {
+				cfg.sections[section] = []configValue{}
+			}
+			continue
+		}
+
+		eq := strings.Index(line, "=")
+		if eq <= 0 {
+			return nil, &ConfigError{File: file, Line: n, Err: ErrConfigSyntax}
+		}
+
+		key := strings.TrimSpace(line[:eq])
+		value, err := configString(stripComment(strings.TrimSpace(line[eq+1:])))
+		if err != nil {
+			return nil, &ConfigError{File: file, Line: n, Err: ErrConfigSyntax}
+		}
+
+		//repeating a key sets the flag more than once
+		if i, exists := seen[section+"\x00"+key]; exists {
+			cfg.sections[section][i].values = append(cfg.sections[section][i].values, value)
+			continue
+		}
+
+		seen[section+"\x00"+key] = len(cfg.sections[section])
+		cfg.sections[section] = append(cfg.sections[section], configValue{key: key, values: []string{value}, line: n})
+	}
+
+	return cfg, scanner.Err()
+}
+
+// configString unquotes s if it's a quoted string.
+func configString(s string) (string, error) {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return strconv.Unquote(s)
+	}
+
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return s[1 : len(s)-1], nil
+	}
+
+	return s, nil
+}
+
+// stripComment removes a trailing " #" comment from an unquoted value.
+func stripComment(s string) string {
+	if len(s) > 0 && (s[0] == '"' || s[0] == '\'') {
+		if end := strings.LastIndexByte(s, s[0]); end > 0 {
+			rest := strings.TrimSpace(s[end+1:])
+			if rest == "" || rest[0] == '#' || rest[0] == ';' {
+				return s[:end+1]
+			}
+		}
+		return s
+	}
+
+	if i := strings.Index(s, " #"); i >= 0 {
+		return strings.TrimSpace(s[:i])
+	}
+
+	return s
+}
@@ -0,0 +1,350 @@
+package genie
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLamp_SetConfigFile(t *testing.T) {
+	t.Run("validate ini config is applied", func(t *testing.T) {
+		content := `
+# who is wishing
+name = "jafar" # the villain
+
+[magic wish]
+count = 3
+`
+		var name string
+		var count int
+		file := filepath.Join(t.TempDir(), "magic.conf")
+		if err := ioutil.WriteFile(file, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.PersistentFlags.StringVar(&name, "name", "genie", "your name")
+		subject.SetConfigFile(file)
+		wish := NewCommand("wish", true)
+		wish.Flags.IntVar(&count, "count", 1, "how many wishes")
+		wish.Run = func(command *Command) error {
+			return nil
+		}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.SetWriters(bytes.NewBufferString(""), bytes.NewBufferString(""))
+
+		_, err := subject.ExecuteWith([]string{"magic", "wish"})
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		if name != "jafar" || count != 3 {
+			t.Errorf("want jafar and 3, got %s and %d", name, count)
+		}
+	})
+
+	t.Run("validate json config is applied", func(t *testing.T) {
+		var name string
+		var count int
+		file := filepath.Join(t.TempDir(), "magic.json")
+		if err := ioutil.WriteFile(file, []byte(`{"magic": {"name": "jafar"}, "magic wish": {"count": 3}}`), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.PersistentFlags.StringVar(&name, "name", "genie", "your name")
+		subject.SetConfigFile(file)
+		wish := NewCommand("wish", true)
+		wish.Flags.IntVar(&count, "count", 1, "how many wishes")
+		wish.Run = func(command *Command) error {
+			return nil
+		}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.SetWriters(bytes.NewBufferString(""), bytes.NewBufferString(""))
+
+		_, err := subject.ExecuteWith([]string{"magic", "wish"})
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		if name != "jafar" || count != 3 {
+			t.Errorf("want jafar and 3, got %s and %d", name, count)
+		}
+	})
+
+	t.Run("validate json large integer is applied", func(t *testing.T) {
+		var count int
+		file := filepath.Join(t.TempDir(), "magic.json")
+		if err := ioutil.WriteFile(file, []byte(`{"magic wish": {"count": 1000000}}`), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.PersistentFlags.String("name", "genie", "your name")
+		subject.SetConfigFile(file)
+		wish := NewCommand("wish", true)
+		wish.Flags.IntVar(&count, "count", 1, "how many wishes")
+		wish.Run = func(command *Command) error {
+			return nil
+		}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.SetWriters(bytes.NewBufferString(""), bytes.NewBufferString(""))
+
+		_, err := subject.ExecuteWith([]string{"magic", "wish"})
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		if count != 1000000 {
+			t.Errorf("want 1000000, got %d", count)
+		}
+	})
+
+	t.Run("validate command section wins for persistent flags", func(t *testing.T) {
+		var name string
+		file := filepath.Join(t.TempDir(), "magic.toml")
+		if err := ioutil.WriteFile(file, []byte("name = 'jafar'\n[\"magic wish\"]\nname = 'aladdin'\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.PersistentFlags.StringVar(&name, "name", "genie", "your name")
+		subject.SetConfigFile(file)
+		wish := NewCommand("wish", true)
+		wish.Flags.Int("count", 1, "how many wishes")
+		wish.Run = func(command *Command) error {
+			return nil
+		}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.SetWriters(bytes.NewBufferString(""), bytes.NewBufferString(""))
+
+		_, err := subject.ExecuteWith([]string{"magic", "wish"})
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		if name != "aladdin" {
+			t.Errorf("want aladdin, got %s", name)
+		}
+	})
+
+	t.Run("validate precedence of config, env, and command line", func(t *testing.T) {
+		t.Setenv("MAGIC_NAME", "aladdin")
+		var name string
+		var count int
+		file := filepath.Join(t.TempDir(), "magic.conf")
+		if err := ioutil.WriteFile(file, []byte("name = jafar\n[magic wish]\ncount = 3\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.PersistentFlags.StringVar(&name, "name", "genie", "your name")
+		subject.SetConfigFile(file)
+		wish := NewCommand("wish", true)
+		wish.Flags.IntVar(&count, "count", 1, "how many wishes")
+		wish.Run = func(command *Command) error {
+			return nil
+		}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.SetWriters(bytes.NewBufferString(""), bytes.NewBufferString(""))
+		subject.AutomaticEnv = true
+
+		_, err := subject.ExecuteWith([]string{"magic", "wish", "--count", "5"})
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		if name != "aladdin" || count != 5 {
+			t.Errorf("want aladdin and 5, got %s and %d", name, count)
+		}
+	})
+
+	t.Run("validate config flag overrides file", func(t *testing.T) {
+		var count int
+		file := filepath.Join(t.TempDir(), "magic.conf")
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.PersistentFlags.String("name", "genie", "your name")
+		subject.SetConfigFile(file)
+		wish := NewCommand("wish", true)
+		wish.Flags.IntVar(&count, "count", 1, "how many wishes")
+		wish.Run = func(command *Command) error {
+			return nil
+		}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.SetWriters(bytes.NewBufferString(""), bytes.NewBufferString(""))
+		other := filepath.Join(t.TempDir(), "other.conf")
+		if err := ioutil.WriteFile(other, []byte("[magic wish]\ncount = 7\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := subject.ExecuteWith([]string{"magic", "--config", other, "wish"})
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		if count != 7 {
+			t.Errorf("want 7, got %d", count)
+		}
+	})
+
+	t.Run("validate missing default file is ignored", func(t *testing.T) {
+		var count int
+		file := filepath.Join(t.TempDir(), "magic.conf")
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.PersistentFlags.String("name", "genie", "your name")
+		subject.SetConfigFile(file)
+		wish := NewCommand("wish", true)
+		wish.Flags.IntVar(&count, "count", 1, "how many wishes")
+		wish.Run = func(command *Command) error {
+			return nil
+		}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.SetWriters(bytes.NewBufferString(""), bytes.NewBufferString(""))
+
+		_, err := subject.ExecuteWith([]string{"magic", "wish"})
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		if count != 1 {
+			t.Errorf("want 1, got %d", count)
+		}
+	})
+
+	t.Run("validate missing provided file errors", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "magic.conf")
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.PersistentFlags.String("name", "genie", "your name")
+		subject.SetConfigFile(file)
+		wish := NewCommand("wish", true)
+		wish.Flags.Int("count", 1, "how many wishes")
+		wish.Run = func(command *Command) error {
+			return nil
+		}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.SetWriters(bytes.NewBufferString(""), bytes.NewBufferString(""))
+
+		_, err := subject.ExecuteWith([]string{"magic", "wish", "--config", file})
+		var got *ConfigError
+		if !errors.As(err, &got) || !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("want config error for missing file, got %v", err)
+		}
+	})
+
+	t.Run("validate interface defined config flag is not loaded", func(t *testing.T) {
+		var cfg string
+		file := filepath.Join(t.TempDir(), "app.yaml")
+		if err := ioutil.WriteFile(file, []byte("name: jafar\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.PersistentFlags.StringVar(&cfg, "config", "", "app config")
+		wish := NewCommand("wish", true)
+		wish.Run = func(command *Command) error {
+			return nil
+		}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.SetWriters(bytes.NewBufferString(""), bytes.NewBufferString(""))
+
+		for _, value := range []string{"c", file} {
+			_, err := subject.ExecuteWith([]string{"magic", "wish", "--config", value})
+			if err != nil {
+				t.Fatalf("want nil, got %s", err)
+			}
+
+			if cfg != value {
+				t.Errorf("want %s, got %s", value, cfg)
+			}
+		}
+	})
+
+	t.Run("validate interface defined config flag is not loaded with config file set", func(t *testing.T) {
+		var cfg string
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.PersistentFlags.StringVar(&cfg, "config", "", "app config")
+		subject.SetConfigFile(filepath.Join(t.TempDir(), "magic.conf"))
+		wish := NewCommand("wish", true)
+		wish.Run = func(command *Command) error {
+			return nil
+		}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.SetWriters(bytes.NewBufferString(""), bytes.NewBufferString(""))
+
+		_, err := subject.ExecuteWith([]string{"magic", "wish", "--config", "c"})
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		if cfg != "c" {
+			t.Errorf("want c, got %s", cfg)
+		}
+	})
+}
+
+func TestConfigError(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		err     error
+		want    string
+	}{
+		{"unknown command", "magic.conf", "[magic wash]\ncount = 3\n", ErrConfigUnknownCommand, `magic.conf:1: unknown command "magic wash"`},
+		{"unknown key", "magic.conf", "[magic wish]\ncont = 3\n", ErrConfigUnknownKey, `magic.conf:2: unknown flag --cont for "magic wish"`},
+		{"local flag of parent", "magic.conf", "count = 3\n", ErrConfigUnknownKey, `magic.conf:1: unknown flag --count for "magic"`},
+		{"invalid value", "magic.conf", "[magic wish]\ncount = three\n", ErrConfigInvalidValue, `magic.conf:2: invalid value "three" for flag --count of "magic wish": parse error`},
+		{"bad section", "magic.conf", "[magic wish\n", ErrConfigSyntax, `magic.conf:1: invalid config syntax`},
+		{"bad line", "magic.conf", "count\n", ErrConfigSyntax, `magic.conf:1: invalid config syntax`},
+		{"bad json", "magic.json", `{"magic": `, ErrConfigSyntax, `magic.json: invalid config syntax: unexpected end of JSON input`},
+		{"json object value", "magic.json", `{"magic wish": {"count": {}}}`, ErrConfigInvalidValue, `magic.json: invalid value "map[]" for flag --count of "magic wish": not a string, number, or boolean`},
+	}
+
+	for _, tt := range tests {
+		t.Run("validate "+tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), tt.file)
+			if err := ioutil.WriteFile(file, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			subject := NewLamp("magic", "0.0.0", true)
+			subject.RootCommand.PersistentFlags.String("name", "genie", "your name")
+			subject.SetConfigFile(file)
+			wish := NewCommand("wish", true)
+			wish.Flags.Int("count", 1, "how many wishes")
+			wish.Run = func(command *Command) error {
+				return nil
+			}
+			subject.RootCommand.SubCommands = []*Command{wish}
+			subject.SetWriters(bytes.NewBufferString(""), bytes.NewBufferString(""))
+
+			_, err := subject.ExecuteWith([]string{"magic", "wish"})
+			var got *ConfigError
+			if !errors.As(err, &got) {
+				t.Fatalf("want config error, got %v", err)
+			}
+
+			if !errors.Is(err, tt.err) {
+				t.Errorf("want %s, got %s", tt.err, got.Err)
+			}
+
+			got.File = filepath.Base(file)
+			if got.Error() != tt.want {
+				t.Errorf("want %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseINIConfig(t *testing.T) {
+	cfg, err := parseINIConfig("magic.conf", []byte("; comment\nname = \"a # b\"\nname = 'c'\n[magic wish]\ncount=3 # three\n"), "magic")
+	if err != nil {
+		t.Fatalf("want nil, got %s", err)
+	}
+
+	names := cfg.sections["magic"][0].values
+	if len(names) != 2 || names[0] != "a # b" || names[1] != "c" {
+		t.Errorf("want [a # b c], got %v", names)
+	}
+
+	count := cfg.sections["magic wish"][0]
+	if count.key != "count" || count.values[0] != "3" || count.line != 5 {
+		t.Errorf("want count 3 on line 5, got %v", count)
+	}
+}
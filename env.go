@@ -0,0 +1,158 @@
+package genie
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// BindEnv binds the named flag to the environment variable, if the variable is set and the flag was not provided on
+// the command line its value is used. A variable set to an empty string is treated as unset, so VAR= can't clear or
+// fail to parse a flag. Persistent flags should be bound on the command they are declared on.
+func (c *Command) BindEnv(name, variable string) {
+	if c.envBindings == nil {
+		c.envBindings = make(map[string]string)
+	}
+	c.envBindings[name] = variable
+}
+
+// FlagFromEnv returns the environment variable the named flag's value was read from, if it was. The environment is
+// only read when the command is executed.
+func (c *Command) FlagFromEnv(name string) (string, bool) {
+	origin, ok := c.flagOrigins[c.canonicalFlag(name)]
+	if !ok || origin.Source != SourceEnv {
+		return "", false
+	}
+
+	return origin.Name, true
+}
+
+// EnvName returns the environment variable name used for the flag of a command with the given path when the Lamp
+// binds flags automatically, e.g. "magic wish" and "dry-run" is MAGIC_WISH_DRY_RUN.
+func EnvName(path, name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9'):
+			return r
+		}
+		return '_'
+	}, path+"_"+name)
+}
+
+// declaringCommand returns the command the named flag is declared on, either this command or the parent whose
+// persistent flags include it.
+func (c *Command) declaringCommand(name string) *Command {
+	if c.Flags != nil && c.Flags.Lookup(name) != nil && !c.globalFlags[name] {
+		return c
+	}
+
+	for cmd := c; cmd != nil; cmd = cmd.parent {
+		if cmd.PersistentFlags != nil && cmd.PersistentFlags.Lookup(name) != nil {
+			return cmd
+		}
+	}
+
+	return nil
+}
+
+// envVar returns the environment variable bound to the named flag, explicit bindings win over automatic ones.
+func (c *Command) envVar(name string) (string, bool) {
+	if variable, ok := c.envBindings[name]; ok {
+		return variable, true
+	}
+
+	declaring := c.declaringCommand(name)
+	if declaring == nil {
+		return "", false
+	}
+
+	if variable, ok := declaring.envBindings[name]; ok {
+		return variable, true
+	}
+
+	if c.lamp != nil && c.lamp.AutomaticEnv {
+		return EnvName(declaring.pathOrName(), name), true
+	}
+
+	return "", false
+}
+
+// applyEnv sets any flags not provided on the command line from their bound environment variables.
+func (c *Command) applyEnv() error {
+	if c.Flags == nil {
+		return nil
+	}
+
+	var err error
+	c.Flags.VisitAll(func(f *flag.Flag) {
+		if _, set := c.flagOrigins[f.Name]; set || err != nil || c.isShorthand(f.Name) {
+			return
+		}
+
+		variable, ok := c.envVar(f.Name)
+		if !ok {
+			return
+		}
+
+		value := os.Getenv(variable)
+		if value == "" {
+			return
+		}
+
+		if setErr := c.Flags.Set(f.Name, value); setErr != nil {
+			err = &FlagError{
+				Path:   c.pathOrName(),
+				Flag:   f.Name,
+				Value:  value,
+				Reason: fmt.Sprintf("from %s: %s", variable, setErr),
+				Err:    ErrFlagInvalid,
+			}
+			return
+		}
+		c.flagOrigins[f.Name] = FlagOrigin{Source: SourceEnv, Name: variable}
+	})
+
+	return err
+}
+
+// envUsage returns the environment variables bound to the command's non-secret flags, with the flag each sets.
+func envUsage(command *Command, variableFormat, flagFormat string) string {
+	var names []string
+	if command.Flags != nil {
+		command.Flags.VisitAll(func(f *flag.Flag) {
+			if !command.flagIsSecret(f.Name) && !command.globalFlags[f.Name] && !command.isShorthand(f.Name) {
+				names = append(names, f.Name)
+			}
+		})
+	}
+	for _, f := range command.inheritedFlags() {
+		names = append(names, f.Name)
+	}
+
+	var usages []string
+	for _, name := range names {
+		if variable, ok := command.envVar(name); ok {
+			usages = append(usages, fmt.Sprintf(variableFormat+"\t"+flagFormat+"\n", variable, dashed(name)))
+		}
+	}
+
+	if len(usages) == 0 {
+		return ""
+	}
+
+	sort.Strings(usages)
+
+	var builder strings.Builder
+	tabWriter := tabwriter.NewWriter(&builder, 0, 0, 4, ' ', tabwriter.DiscardEmptyColumns)
+	for _, s := range usages {
+		_, _ = tabWriter.Write([]byte(s))
+	}
+
+	_ = tabWriter.Flush()
+	return builder.String()
+}
@@ -0,0 +1,277 @@
+package genie
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEnvName(t *testing.T) {
+	want := "MAGIC_WISH_SUB_DRY_RUN"
+	got := EnvName("magic wish sub", "dry-run")
+	if got != want {
+		t.Errorf("want %s, got %s", want, got)
+	}
+}
+
+func TestCommand_BindEnv(t *testing.T) {
+	t.Run("validate explicit binding is applied", func(t *testing.T) {
+		t.Setenv("WISHES", "3")
+		var count int
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.PersistentFlags.String("name", "genie", "your name")
+		wish := NewCommand("wish", true)
+		wish.Flags.IntVar(&count, "wish-count", 1, "how many wishes")
+		wish.Run = func(command *Command) error {
+			return nil
+		}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.SetWriters(bytes.NewBufferString(""), bytes.NewBufferString(""))
+		wish.BindEnv("wish-count", "WISHES")
+
+		_, err := subject.ExecuteWith([]string{"magic", "wish"})
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		if count != 3 {
+			t.Errorf("want 3, got %d", count)
+		}
+
+		if variable, ok := wish.FlagFromEnv("wish-count"); !ok || variable != "WISHES" {
+			t.Errorf("want WISHES, got %s", variable)
+		}
+
+		if wish.FlagWasProvided("wish-count") {
+			t.Errorf("want not provided, got provided")
+		}
+	})
+
+	t.Run("validate command line wins over env", func(t *testing.T) {
+		t.Setenv("WISHES", "3")
+		var count int
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.PersistentFlags.String("name", "genie", "your name")
+		wish := NewCommand("wish", true)
+		wish.Flags.IntVar(&count, "wish-count", 1, "how many wishes")
+		wish.Run = func(command *Command) error {
+			return nil
+		}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.SetWriters(bytes.NewBufferString(""), bytes.NewBufferString(""))
+		wish.BindEnv("wish-count", "WISHES")
+
+		_, err := subject.ExecuteWith([]string{"magic", "wish", "--wish-count", "5"})
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		if count != 5 {
+			t.Errorf("want 5, got %d", count)
+		}
+
+		if _, ok := wish.FlagFromEnv("wish-count"); ok {
+			t.Errorf("want not from env")
+		}
+
+		if !wish.FlagWasProvided("wish-count") {
+			t.Errorf("want provided, got not provided")
+		}
+	})
+
+	t.Run("validate persistent flag binding on declaring command", func(t *testing.T) {
+		t.Setenv("GENIE_NAME", "jafar")
+		var name string
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.PersistentFlags.String("name", "genie", "your name")
+		wish := NewCommand("wish", true)
+		wish.Flags.Int("wish-count", 1, "how many wishes")
+		wish.Run = func(command *Command) error {
+			name = command.Flags.Lookup("name").Value.String()
+			return nil
+		}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.SetWriters(bytes.NewBufferString(""), bytes.NewBufferString(""))
+		subject.RootCommand.BindEnv("name", "GENIE_NAME")
+
+		_, err := subject.ExecuteWith([]string{"magic", "wish"})
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		if name != "jafar" {
+			t.Errorf("want jafar, got %s", name)
+		}
+	})
+
+	t.Run("validate invalid env value errors", func(t *testing.T) {
+		want := `invalid value "three" for flag --wish-count: from WISHES: parse error`
+		t.Setenv("WISHES", "three")
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.PersistentFlags.String("name", "genie", "your name")
+		wish := NewCommand("wish", true)
+		wish.Flags.Int("wish-count", 1, "how many wishes")
+		wish.Run = func(command *Command) error {
+			return nil
+		}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.SetWriters(bytes.NewBufferString(""), bytes.NewBufferString(""))
+		wish.BindEnv("wish-count", "WISHES")
+
+		_, err := subject.ExecuteWith([]string{"magic", "wish"})
+		if !errors.Is(err, ErrFlagInvalid) {
+			t.Fatalf("want %s, got %v", ErrFlagInvalid, err)
+		}
+
+		if err.Error() != want {
+			t.Errorf("want %s, got %s", want, err)
+		}
+	})
+
+	t.Run("validate empty env value is treated as unset", func(t *testing.T) {
+		var count int
+		t.Setenv("WISHES", "")
+		subject := NewLamp("magic", "0.0.0", true)
+		wish := NewCommand("wish", true)
+		wish.Flags.IntVar(&count, "wish-count", 1, "how many wishes")
+		wish.Run = func(command *Command) error {
+			return nil
+		}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.SetWriters(bytes.NewBufferString(""), bytes.NewBufferString(""))
+		wish.BindEnv("wish-count", "WISHES")
+
+		_, err := subject.ExecuteWith([]string{"magic", "wish"})
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		if count != 1 {
+			t.Errorf("want 1, got %d", count)
+		}
+
+		if _, ok := wish.FlagFromEnv("wish-count"); ok {
+			t.Error("want flag not from env")
+		}
+	})
+}
+
+func TestLamp_AutomaticEnv(t *testing.T) {
+	t.Run("validate automatic names from command path", func(t *testing.T) {
+		t.Setenv("MAGIC_WISH_WISH_COUNT", "4")
+		t.Setenv("MAGIC_NAME", "aladdin")
+		var name string
+		var count int
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.PersistentFlags.String("name", "genie", "your name")
+		wish := NewCommand("wish", true)
+		wish.Flags.IntVar(&count, "wish-count", 1, "how many wishes")
+		wish.Run = func(command *Command) error {
+			name = command.Flags.Lookup("name").Value.String()
+			return nil
+		}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.SetWriters(bytes.NewBufferString(""), bytes.NewBufferString(""))
+		subject.AutomaticEnv = true
+
+		_, err := subject.ExecuteWith([]string{"magic", "wish"})
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		if count != 4 {
+			t.Errorf("want 4, got %d", count)
+		}
+
+		if name != "aladdin" {
+			t.Errorf("want aladdin, got %s", name)
+		}
+	})
+
+	t.Run("validate automatic names are not used when disabled", func(t *testing.T) {
+		t.Setenv("MAGIC_WISH_WISH_COUNT", "4")
+		var count int
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.PersistentFlags.String("name", "genie", "your name")
+		wish := NewCommand("wish", true)
+		wish.Flags.IntVar(&count, "wish-count", 1, "how many wishes")
+		wish.Run = func(command *Command) error {
+			return nil
+		}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.SetWriters(bytes.NewBufferString(""), bytes.NewBufferString(""))
+
+		_, err := subject.ExecuteWith([]string{"magic", "wish"})
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		if count != 1 {
+			t.Errorf("want 1, got %d", count)
+		}
+	})
+}
+
+func TestEnvUsage(t *testing.T) {
+	t.Run("validate environment section", func(t *testing.T) {
+		want := "\nENVIRONMENT:\nMAGIC_NAME    --name\nWISHES        --wish-count\n"
+		b := bytes.NewBufferString("")
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.PersistentFlags.String("name", "genie", "your name")
+		wish := NewCommand("wish", true)
+		wish.Flags.Int("wish-count", 1, "how many wishes")
+		wish.Run = func(command *Command) error {
+			return nil
+		}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.AutomaticEnv = true
+		subject.SetWriters(b, b)
+		wish.BindEnv("wish-count", "WISHES")
+
+		_, err := subject.ExecuteWith([]string{"magic", "wish", "--help"})
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("want %s, got %s", want, b.String())
+		}
+	})
+
+	t.Run("validate marked environment section", func(t *testing.T) {
+		want := "::ENV::WISHES::ENV-END::    ::FLAG::--wish-count::FLAG-END::\n"
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.PersistentFlags.String("name", "genie", "your name")
+		wish := NewCommand("wish", true)
+		wish.Flags.Int("wish-count", 1, "how many wishes")
+		wish.Run = func(command *Command) error {
+			return nil
+		}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.SetWriters(bytes.NewBufferString(""), bytes.NewBufferString(""))
+		wish.BindEnv("wish-count", "WISHES")
+
+		got := DefaultCommandUsageMarkedFunc(wish)
+		if !strings.Contains(got, "::HEADER::ENVIRONMENT:::HEADER-END::\n"+want) {
+			t.Errorf("want %s, got %s", want, got)
+		}
+	})
+
+	t.Run("validate no environment section without bindings", func(t *testing.T) {
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.PersistentFlags.String("name", "genie", "your name")
+		wish := NewCommand("wish", true)
+		wish.Flags.Int("wish-count", 1, "how many wishes")
+		wish.Run = func(command *Command) error {
+			return nil
+		}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.SetWriters(bytes.NewBufferString(""), bytes.NewBufferString(""))
+
+		got := DefaultCommandUsageFunc(wish)
+		if strings.Contains(got, "ENVIRONMENT") {
+			t.Errorf("want no environment section, got %s", got)
+		}
+	})
+}
@@ -0,0 +1,138 @@
+package genie
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Error string
+
+func (e Error) Error() string { return string(e) }
+
+var (
+	ErrNoOp                = Error("noop")
+	ErrNoArgs              = Error("arguments not provided")
+	ErrCommandDepthInvalid = Error("invalid command depth")
+	ErrCommandNotFound     = Error("command not found")
+	ErrCommandNotRunnable  = Error("command not runnable")
+	ErrCommandAmbiguous    = Error("ambiguous command")
+	ErrValidation          = Error("validation failed")
+)
+
+// UsageError describes an error caused by invalid commands, flags, or arguments being provided. Every usage related
+// error returned by genie can be retrieved as a *UsageError using errors.As, providing a common way to find the command
+// path, offending token, and any suggestions. You can also return a UsageError from Check or Run to have the error
+// treated as a usage error by Lamp.Main.
+type UsageError struct {
+	Path        string //path of the command the error occurred on
+	Token       string //the offending command, flag, or argument, if any
+	Suggestions []string
+	Err         error
+}
+
+func (e *UsageError) Error() string {
+	//the typed errors already describe themselves, only sentinels need the path and token added
+	if _, ok := e.Err.(Error); !ok {
+		return e.Err.Error()
+	}
+
+	if e.Token != "" {
+		return fmt.Sprintf("%s %q for %q%s", e.Err, e.Token, e.Path, didYouMean(e.Suggestions, `"%s"`))
+	}
+
+	return fmt.Sprintf("%s for %q", e.Err, e.Path)
+}
+
+func (e *UsageError) Unwrap() error {
+	return e.Err
+}
+
+// asUsageError sets target to usage if target is a **UsageError, it's used to implement As for the usage errors.
+func asUsageError(target interface{}, usage *UsageError) bool {
+	if t, ok := target.(**UsageError); ok {
+		*t = usage
+		return true
+	}
+
+	return false
+}
+
+// NotRunnableError is returned when the command found has no Run, it wraps ErrCommandNotRunnable.
+type NotRunnableError struct {
+	Path string //path of the command that could not be run
+}
+
+func (e *NotRunnableError) Error() string {
+	return fmt.Sprintf("%s: %q", ErrCommandNotRunnable, e.Path)
+}
+
+func (e *NotRunnableError) Unwrap() error {
+	return ErrCommandNotRunnable
+}
+
+func (e *NotRunnableError) As(target interface{}) bool {
+	return asUsageError(target, &UsageError{Path: e.Path, Err: e})
+}
+
+// ValidationError is returned when a command's Check returns one of genie's sentinel errors, it wraps both
+// ErrValidation and the sentinel so either can be found with errors.Is. Any other error is returned unchanged.
+type ValidationError struct {
+	Path string //path of the command that failed validation
+	Err  error  //the error returned by Check
+}
+
+func (e *ValidationError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// AmbiguousCommandError is returned when prefix matching is enabled and a prefix matches more than one command, it
+// wraps ErrCommandAmbiguous.
+type AmbiguousCommandError struct {
+	Path       string //path of the command the prefix was provided to
+	Name       string //the ambiguous prefix
+	Candidates []string
+}
+
+func (e *AmbiguousCommandError) Error() string {
+	quoted := make([]string, len(e.Candidates))
+	for i, c := range e.Candidates {
+		quoted[i] = fmt.Sprintf("%q", c)
+	}
+
+	return fmt.Sprintf("ambiguous command %q for %q, could be %s", e.Name, e.Path, strings.Join(quoted, ", "))
+}
+
+func (e *AmbiguousCommandError) Unwrap() error {
+	return ErrCommandAmbiguous
+}
+
+func (e *AmbiguousCommandError) As(target interface{}) bool {
+	return asUsageError(target, &UsageError{Path: e.Path, Token: e.Name, Suggestions: e.Candidates, Err: e})
+}
+
+// CommandNotFoundError is returned when an unknown command is provided, it wraps ErrCommandNotFound.
+type CommandNotFoundError struct {
+	Path        string //path of the command the unknown command was provided to
+	Name        string //the unknown command
+	Suggestions []string
+}
+
+func (e *CommandNotFoundError) Error() string {
+	return fmt.Sprintf("unknown command %q for %q%s", e.Name, e.Path, didYouMean(e.Suggestions, `"%s"`))
+}
+
+func (e *CommandNotFoundError) Unwrap() error {
+	return ErrCommandNotFound
+}
+
+func (e *CommandNotFoundError) As(target interface{}) bool {
+	return asUsageError(target, &UsageError{Path: e.Path, Token: e.Name, Suggestions: e.Suggestions, Err: e})
+}
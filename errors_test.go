@@ -0,0 +1,217 @@
+package genie
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestError_Error(t *testing.T) {
+	t.Run("validate error string", func(t *testing.T) {
+		want := "heyo error"
+		got := Error("heyo error")
+		if want != got.Error() {
+			t.Errorf("want %s, got %s", want, got)
+		}
+	})
+}
+
+func TestUsageError_Error(t *testing.T) {
+	t.Run("validate error message with token", func(t *testing.T) {
+		want := `invalid command depth "five" for "magic one five"; did you mean "four"?`
+		subject := &UsageError{Path: "magic one five", Token: "five", Suggestions: []string{"four"}, Err: ErrCommandDepthInvalid}
+		if subject.Error() != want {
+			t.Errorf("want %s, got %s", want, subject.Error())
+		}
+	})
+
+	t.Run("validate error message without token", func(t *testing.T) {
+		want := `invalid command depth for "magic"`
+		subject := &UsageError{Path: "magic", Err: ErrCommandDepthInvalid}
+		if subject.Error() != want {
+			t.Errorf("want %s, got %s", want, subject.Error())
+		}
+	})
+
+	t.Run("validate error message of wrapped typed error", func(t *testing.T) {
+		want := `unknown command "wsh" for "magic"`
+		subject := &UsageError{Path: "magic", Token: "wsh", Err: &CommandNotFoundError{Path: "magic", Name: "wsh"}}
+		if subject.Error() != want {
+			t.Errorf("want %s, got %s", want, subject.Error())
+		}
+	})
+}
+
+func TestUsageError_As(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		sentinel    error
+		path        string
+		token       string
+		suggestions []string
+	}{
+		{"command not found", &CommandNotFoundError{Path: "magic", Name: "wsh", Suggestions: []string{"wish"}}, ErrCommandNotFound, "magic", "wsh", []string{"wish"}},
+		{"ambiguous command", &AmbiguousCommandError{Path: "magic", Name: "w", Candidates: []string{"wash", "wish"}}, ErrCommandAmbiguous, "magic", "w", []string{"wash", "wish"}},
+		{"not runnable", &NotRunnableError{Path: "magic wish"}, ErrCommandNotRunnable, "magic wish", "", nil},
+		{"flag error", &FlagError{Path: "magic", Flag: "tset", Suggestions: []string{"test"}, Err: ErrFlagUnknown}, ErrFlagUnknown, "magic", "--tset", []string{"--test"}},
+		{"arg error", &ArgError{Path: "magic copy", Arg: "count", Value: "three", Err: ErrArgInvalid}, ErrArgInvalid, "magic copy", "three", nil},
+		{"missing arg error", &ArgError{Path: "magic copy", Arg: "src", Err: ErrArgMissing}, ErrArgMissing, "magic copy", "src", nil},
+		{"args count error", &ArgsCountError{Path: "magic copy", Min: 2, Max: 2, Got: 3}, ErrArgsCount, "magic copy", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run("validate "+tt.name+" can be found as usage error", func(t *testing.T) {
+			var got *UsageError
+			if !errors.As(tt.err, &got) {
+				t.Fatalf("want usage error, got %T", tt.err)
+			}
+
+			if got.Path != tt.path {
+				t.Errorf("want %s, got %s", tt.path, got.Path)
+			}
+
+			if got.Token != tt.token {
+				t.Errorf("want %s, got %s", tt.token, got.Token)
+			}
+
+			if len(got.Suggestions) != len(tt.suggestions) {
+				t.Errorf("want %v, got %v", tt.suggestions, got.Suggestions)
+			}
+
+			if got.Error() != tt.err.Error() {
+				t.Errorf("want %s, got %s", tt.err, got)
+			}
+
+			if !errors.Is(got, tt.sentinel) {
+				t.Errorf("want %s, got %s", tt.sentinel, got)
+			}
+		})
+	}
+}
+
+func TestNotRunnableError_Error(t *testing.T) {
+	want := `command not runnable: "magic wish"`
+	subject := &NotRunnableError{Path: "magic wish"}
+	if subject.Error() != want {
+		t.Errorf("want %s, got %s", want, subject.Error())
+	}
+
+	if !errors.Is(subject, ErrCommandNotRunnable) {
+		t.Errorf("want %s, got %s", ErrCommandNotRunnable, subject)
+	}
+}
+
+func TestValidationError(t *testing.T) {
+	t.Run("validate check error is returned unchanged", func(t *testing.T) {
+		want := errors.New("bad wish")
+		subject := NewCommand("wish", true)
+		subject.Check = func(command *Command) error {
+			return want
+		}
+		subject.Run = func(command *Command) error {
+			return nil
+		}
+
+		got := subject.run([]string{})
+		if got != want {
+			t.Errorf("want %s, got %s", want, got)
+		}
+	})
+
+	t.Run("validate check sentinel error is wrapped", func(t *testing.T) {
+		checkErr := ErrArgInvalid
+		subject := NewCommand("wish", true)
+		subject.Check = func(command *Command) error {
+			return checkErr
+		}
+		subject.Run = func(command *Command) error {
+			return nil
+		}
+
+		got := subject.run([]string{})
+		if !errors.Is(got, checkErr) {
+			t.Errorf("want %s, got %s", checkErr, got)
+		}
+
+		if !errors.Is(got, ErrValidation) {
+			t.Errorf("want %s, got %s", ErrValidation, got)
+		}
+
+		var validationErr *ValidationError
+		if !errors.As(got, &validationErr) || validationErr.Path != "wish" {
+			t.Errorf("want validation error for wish, got %v", got)
+		}
+
+		if got.Error() != checkErr.Error() {
+			t.Errorf("want %s, got %s", checkErr, got)
+		}
+	})
+
+	t.Run("validate usage error returned by check is a usage error", func(t *testing.T) {
+		subject := NewCommand("wish", true)
+		subject.Check = func(command *Command) error {
+			return &UsageError{Path: command.Path(), Token: "-x", Err: ErrFlagUnknown}
+		}
+		subject.Run = func(command *Command) error {
+			return nil
+		}
+
+		got := subject.run([]string{})
+		if !IsUsageError(got) {
+			t.Errorf("want usage error, got %s", got)
+		}
+
+		if ExitCode(got) != ExitCodeUsage {
+			t.Errorf("want %d, got %d", ExitCodeUsage, ExitCode(got))
+		}
+	})
+}
+
+func TestAmbiguousCommandError_Error(t *testing.T) {
+	t.Run("validate error string", func(t *testing.T) {
+		want := `ambiguous command "w" for "magic", could be "wash", "wish"`
+		got := &AmbiguousCommandError{Path: "magic", Name: "w", Candidates: []string{"wash", "wish"}}
+		if got.Error() != want {
+			t.Errorf("want %s, got %s", want, got)
+		}
+		if !errors.Is(got, ErrCommandAmbiguous) {
+			t.Errorf("want %s, got %s", ErrCommandAmbiguous, got.Unwrap())
+		}
+	})
+}
+
+func TestCommandNotFoundError_Error(t *testing.T) {
+	testCases := []struct {
+		name    string
+		subject *CommandNotFoundError
+		want    string
+	}{
+		{
+			name:    "no suggestions",
+			subject: &CommandNotFoundError{Path: "magic", Name: "zzz"},
+			want:    `unknown command "zzz" for "magic"`,
+		},
+		{
+			name:    "one suggestion",
+			subject: &CommandNotFoundError{Path: "magic", Name: "wsh", Suggestions: []string{"wish"}},
+			want:    `unknown command "wsh" for "magic"; did you mean "wish"?`,
+		},
+		{
+			name:    "many suggestions",
+			subject: &CommandNotFoundError{Path: "magic", Name: "wsh", Suggestions: []string{"wish", "wash", "swish"}},
+			want:    `unknown command "wsh" for "magic"; did you mean "wish", "wash" or "swish"?`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.subject.Error()
+			if got != tc.want {
+				t.Errorf("want %s, got %s", tc.want, got)
+			}
+			if !errors.Is(tc.subject, ErrCommandNotFound) {
+				t.Errorf("want %s, got %s", ErrCommandNotFound, tc.subject.Unwrap())
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package genie
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ItemFunc is called once for each piped item when fanning out, anything written to out is written to Command.Out
+// once the item is done, so output of parallel items is never interleaved.
+type ItemFunc func(command *Command, item string, out io.Writer) error
+
+// FanOutPolicy decides what happens when an item fails while fanning out.
+type FanOutPolicy int
+
+const (
+	FailFast   FanOutPolicy = iota //stop starting new items after the first failure
+	CollectAll                     //run every item and report all failures
+)
+
+// FanOut runs a command once per piped line, like xargs. Blank lines are skipped.
+type FanOut struct {
+	Each     ItemFunc     //called for each item
+	Parallel int          //number of items run at once, the --parallel flag overrides this, defaults to 1
+	Ordered  bool         //if true output is written in input order, otherwise as items finish
+	Policy   FanOutPolicy //what to do when an item fails
+}
+
+// ItemError describes the failure of a single item while fanning out.
+type ItemError struct {
+	Line int //the line of input the item was read from
+	Item string
+	Err  error
+}
+
+func (e *ItemError) Error() string {
+	return fmt.Sprintf("line %d %q: %s", e.Line, e.Item, e.Err)
+}
+
+func (e *ItemError) Unwrap() error {
+	return e.Err
+}
+
+// FanOutError is returned when one or more items failed while fanning out.
+type FanOutError struct {
+	Path   string //path of the command that fanned out
+	Total  int    //the number of items read
+	Errors []*ItemError
+}
+
+func (e *FanOutError) Error() string {
+	var b strings.Builder
+	_, _ = fmt.Fprintf(&b, "%q failed for %d of %d items", e.Path, len(e.Errors), e.Total)
+	for _, err := range e.Errors {
+		_, _ = fmt.Fprintf(&b, "\n  %s", err)
+	}
+
+	return b.String()
+}
+
+// Unwrap returns the first item error, so errors.Is and errors.As find it.
+func (e *FanOutError) Unwrap() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+
+	return e.Errors[0]
+}
+
+// SetFanOut sets how the command fans out over piped input, and adds the --parallel flag to the command's flags.
+func (c *Command) SetFanOut(fanOut *FanOut) {
+	c.FanOut = fanOut
+	if c.Flags == nil {
+		c.Flags = c.newFlagSet()
+	}
+
+	c.addParallelFlag()
+}
+
+// addParallelFlag adds the --parallel flag if the command fans out and doesn't already have one, so a FanOut set
+// directly on the command gets the flag when it's run.
+func (c *Command) addParallelFlag() {
+	if c.FanOut == nil {
+		return
+	}
+
+	if c.Flags == nil {
+		c.Flags = c.newFlagSet()
+	}
+
+	if c.Flags.Lookup("parallel") == nil {
+		c.Flags.Int("parallel", c.FanOut.Parallel, "number of piped items to run at once")
+	}
+}
+
+// fansOut returns true if the command should run FanOut instead of Run.
+func (c *Command) fansOut() bool {
+	return c.FanOut != nil && c.FanOut.Each != nil && c.IsPiped()
+}
+
+// parallel returns the number of items to run at once, from the --parallel flag if defined.
+func (c *Command) parallel() int {
+	n := c.FanOut.Parallel
+	if c.Flags != nil {
+		if f := c.Flags.Lookup("parallel"); f != nil {
+			if getter, ok := f.Value.(interface{ Get() interface{} }); ok {
+				if v, ok := getter.Get().(int); ok {
+					n = v
+				}
+			}
+		}
+	}
+
+	if n < 1 {
+		return 1
+	}
+
+	return n
+}
+
+// runFanOut calls FanOut.Each for each line of piped input.
+func (c *Command) runFanOut() error {
+	type item struct {
+		line  int
+		index int
+		value string
+	}
+
+	parent := c.Context()
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+	c.ctx = ctx
+	defer func() { c.ctx = parent }()
+
+	var (
+		mu      sync.Mutex
+		errs    []*ItemError
+		pending = make(map[int][]byte)
+		next    int
+	)
+
+	//output is written as each item finishes, or in input order when ordered
+	write := func(index int, out []byte) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !c.FanOut.Ordered {
+			if c.Out != nil {
+				_, _ = c.Out.Write(out)
+			}
+			return
+		}
+
+		pending[index] = out
+		for {
+			out, ok := pending[next]
+			if !ok {
+				return
+			}
+			delete(pending, next)
+			if c.Out != nil {
+				_, _ = c.Out.Write(out)
+			}
+			next++
+		}
+	}
+
+	items := make(chan item)
+	var wg sync.WaitGroup
+	for i := 0; i < c.parallel(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for it := range items {
+				var out bytes.Buffer
+				if ctx.Err() != nil {
+					//after a fail fast the remaining items are skipped, but still written so ordered output continues
+					write(it.index, nil)
+					continue
+				}
+
+				if err := c.runItem(it.value, &out); err != nil {
+					mu.Lock()
+					var panicErr *PanicError
+					if errors.As(err, &panicErr) {
+						panicErr.tell(c.Err)
+					}
+					errs = append(errs, &ItemError{Line: it.line, Item: it.value, Err: err})
+					mu.Unlock()
+					if c.FanOut.Policy == FailFast {
+						cancel()
+					}
+				}
+
+				write(it.index, out.Bytes())
+			}
+		}()
+	}
+
+	line, total := 0, 0
+	readErr := c.ReadLines(func(value string) error {
+		line++
+		if strings.TrimSpace(value) == "" {
+			return nil
+		}
+
+		select {
+		case items <- item{line: line, index: total, value: value}:
+			total++
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	close(items)
+	wg.Wait()
+
+	if len(errs) > 0 {
+		sort.Slice(errs, func(i, j int) bool { return errs[i].Line < errs[j].Line })
+		if c.FanOut.Policy == FailFast {
+			errs = errs[:1]
+		}
+		return &FanOutError{Path: c.pathOrName(), Total: total, Errors: errs}
+	}
+
+	if readErr != nil {
+		return readErr
+	}
+
+	return parent.Err()
+}
+
+// runItem calls FanOut.Each for the item. Items run outside the command's own panic recovery, so if panics are
+// recovered a panic is returned as a PanicError for the item.
+func (c *Command) runItem(item string, out io.Writer) (err error) {
+	if c.recoversPanics() {
+		defer func() {
+			if value := recover(); value != nil {
+				err = c.newPanicError(value)
+			}
+		}()
+	}
+
+	return c.FanOut.Each(c, item, out)
+}
@@ -0,0 +1,298 @@
+package genie
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCommand_runFanOut(t *testing.T) {
+	upper := func(command *Command, item string, out io.Writer) error {
+		_, err := fmt.Fprintln(out, strings.ToUpper(item))
+		return err
+	}
+
+	t.Run("validate each item is run in order", func(t *testing.T) {
+		want := "ONE\nTWO\nTHREE\n"
+		b := bytes.NewBufferString("")
+		subject := NewCommand("wish", true)
+		subject.SetIn(strings.NewReader("one\n\ntwo\nthree\n"))
+		subject.SetOut(b)
+		subject.Piped = AlwaysPiped
+		subject.SetFanOut(&FanOut{Each: upper})
+
+		err := subject.run([]string{})
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		if b.String() != want {
+			t.Errorf("want %s, got %s", want, b.String())
+		}
+	})
+
+	t.Run("validate ordered output with parallel items", func(t *testing.T) {
+		want := "A\nB\nC\nD\n"
+		b := bytes.NewBufferString("")
+		subject := NewCommand("wish", true)
+		subject.SetIn(strings.NewReader("a\nb\nc\nd\n"))
+		subject.SetOut(b)
+		subject.Piped = AlwaysPiped
+		subject.SetFanOut(&FanOut{
+			Each: func(command *Command, item string, out io.Writer) error {
+				//later items finish first
+				time.Sleep(time.Duration('e'-item[0]) * 5 * time.Millisecond)
+				return upper(command, item, out)
+			},
+			Ordered: true,
+		})
+
+		err := subject.run([]string{"--parallel", "4"})
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		if b.String() != want {
+			t.Errorf("want %s, got %s", want, b.String())
+		}
+	})
+
+	t.Run("validate unordered output with parallel items", func(t *testing.T) {
+		want := "D\nC\nB\nA\n"
+		b := bytes.NewBufferString("")
+		subject := NewCommand("wish", true)
+		subject.SetIn(strings.NewReader("a\nb\nc\nd\n"))
+		subject.SetOut(b)
+		subject.Piped = AlwaysPiped
+		subject.SetFanOut(&FanOut{
+			Each: func(command *Command, item string, out io.Writer) error {
+				time.Sleep(time.Duration('e'-item[0]) * 20 * time.Millisecond)
+				return upper(command, item, out)
+			},
+			Parallel: 4,
+		})
+
+		err := subject.run([]string{})
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		if b.String() != want {
+			t.Errorf("want %s, got %s", want, b.String())
+		}
+	})
+
+	t.Run("validate parallel limits items run at once", func(t *testing.T) {
+		var running, most int32
+		b := bytes.NewBufferString("")
+		subject := NewCommand("wish", true)
+		subject.SetIn(strings.NewReader("a\nb\nc\nd\ne\nf\n"))
+		subject.SetOut(b)
+		subject.Piped = AlwaysPiped
+		subject.SetFanOut(&FanOut{
+			Each: func(command *Command, item string, out io.Writer) error {
+				n := atomic.AddInt32(&running, 1)
+				for {
+					m := atomic.LoadInt32(&most)
+					if n <= m || atomic.CompareAndSwapInt32(&most, m, n) {
+						break
+					}
+				}
+				time.Sleep(10 * time.Millisecond)
+				atomic.AddInt32(&running, -1)
+				return nil
+			},
+		})
+
+		err := subject.run([]string{"--parallel", "2"})
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		if most != 2 {
+			t.Errorf("want 2, got %d", most)
+		}
+	})
+
+	t.Run("validate parallel flag is added when fan out is set directly", func(t *testing.T) {
+		want := "A\nB\n"
+		b := bytes.NewBufferString("")
+		subject := NewCommand("wish", true)
+		subject.SetIn(strings.NewReader("a\nb\n"))
+		subject.SetOut(b)
+		subject.Piped = AlwaysPiped
+		subject.FanOut = &FanOut{Each: upper, Ordered: true}
+
+		err := subject.run([]string{"--parallel", "2"})
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		if b.String() != want {
+			t.Errorf("want %s, got %s", want, b.String())
+		}
+	})
+
+	t.Run("validate collect all reports every failure", func(t *testing.T) {
+		want := "\"wish\" failed for 2 of 3 items\n  line 1 \"a\": bad a\n  line 4 \"c\": bad c"
+		b := bytes.NewBufferString("")
+		subject := NewCommand("wish", true)
+		subject.SetIn(strings.NewReader("a\nb\n\nc\n"))
+		subject.SetOut(b)
+		subject.Piped = AlwaysPiped
+		subject.SetFanOut(&FanOut{
+			Each: func(command *Command, item string, out io.Writer) error {
+				if item == "b" {
+					return upper(command, item, out)
+				}
+				return errors.New("bad " + item)
+			},
+			Policy:   CollectAll,
+			Parallel: 2,
+		})
+
+		err := subject.run([]string{})
+		var got *FanOutError
+		if !errors.As(err, &got) {
+			t.Fatalf("want fan out error, got %v", err)
+		}
+
+		if got.Error() != want {
+			t.Errorf("want %s, got %s", want, got)
+		}
+
+		if b.String() != "B\n" {
+			t.Errorf("want B, got %s", b.String())
+		}
+	})
+
+	t.Run("validate fail fast stops after first failure", func(t *testing.T) {
+		var ran int32
+		b := bytes.NewBufferString("")
+		subject := NewCommand("wish", true)
+		subject.SetIn(strings.NewReader("a\nb\nc\nd\n"))
+		subject.SetOut(b)
+		subject.Piped = AlwaysPiped
+		subject.SetFanOut(&FanOut{
+			Each: func(command *Command, item string, out io.Writer) error {
+				atomic.AddInt32(&ran, 1)
+				if item == "b" {
+					return errors.New("bad b")
+				}
+				return nil
+			},
+		})
+
+		err := subject.run([]string{})
+		var got *ItemError
+		if !errors.As(err, &got) || got.Item != "b" || got.Line != 2 {
+			t.Fatalf("want item error for b, got %v", err)
+		}
+
+		if ran != 2 {
+			t.Errorf("want 2, got %d", ran)
+		}
+	})
+
+	t.Run("validate panicking item is recovered when panics are recovered", func(t *testing.T) {
+		e := bytes.NewBufferString("")
+		subject := NewCommand("wish", true)
+		subject.SetIn(strings.NewReader("a\nb\n"))
+		subject.SetOut(bytes.NewBufferString(""))
+		subject.SetErr(e)
+		subject.Piped = AlwaysPiped
+		subject.RecoverPanics = true
+		subject.SetFanOut(&FanOut{
+			Each: func(command *Command, item string, out io.Writer) error {
+				if item == "b" {
+					panic("boom")
+				}
+				return nil
+			},
+			Parallel: 2,
+		})
+
+		err := subject.run([]string{})
+		var got *PanicError
+		if !errors.As(err, &got) || got.Value != "boom" {
+			t.Fatalf("want panic error, got %v", err)
+		}
+
+		var itemErr *ItemError
+		if !errors.As(err, &itemErr) || itemErr.Item != "b" {
+			t.Errorf("want item error for b, got %v", err)
+		}
+
+		if !strings.Contains(e.String(), "something went wrong") {
+			t.Errorf("want friendly message, got %s", e.String())
+		}
+	})
+
+	t.Run("validate items are run without an output writer", func(t *testing.T) {
+		subject := &Command{
+			Name:  "wish",
+			In:    strings.NewReader("a\nb\n"),
+			Piped: AlwaysPiped,
+			FanOut: &FanOut{
+				Each:    upper,
+				Ordered: true,
+			},
+		}
+
+		err := subject.run([]string{})
+		if err != nil {
+			t.Errorf("want nil, got %s", err)
+		}
+	})
+
+	t.Run("validate run is called when not piped", func(t *testing.T) {
+		want := "ran"
+		b := bytes.NewBufferString("")
+		subject := NewCommand("wish", true)
+		subject.SetIn(strings.NewReader("a\n"))
+		subject.SetOut(b)
+		subject.SetFanOut(&FanOut{Each: upper})
+		subject.Piped = NeverPiped
+		subject.Run = func(command *Command) error {
+			_, err := command.Out.Write([]byte("ran"))
+			return err
+		}
+
+		err := subject.run([]string{})
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		if b.String() != want {
+			t.Errorf("want %s, got %s", want, b.String())
+		}
+	})
+
+	t.Run("validate not runnable when not piped without run", func(t *testing.T) {
+		subject := NewCommand("wish", true)
+		subject.SetIn(strings.NewReader("a\n"))
+		subject.SetOut(bytes.NewBufferString(""))
+		subject.SetFanOut(&FanOut{Each: upper})
+		subject.Piped = NeverPiped
+
+		err := subject.run([]string{})
+		if !errors.Is(err, ErrCommandNotRunnable) {
+			t.Errorf("want %s, got %s", ErrCommandNotRunnable, err)
+		}
+	})
+
+	t.Run("validate parallel flag shows in usage", func(t *testing.T) {
+		subject := NewCommand("wish", true)
+		subject.SetFanOut(&FanOut{Each: upper, Parallel: 3})
+
+		got := subject.ShowUsage()
+		if !strings.Contains(got, "-parallel") || !strings.Contains(got, "(default 3)") {
+			t.Errorf("want parallel flag in usage, got %s", got)
+		}
+	})
+}
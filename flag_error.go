@@ -0,0 +1,147 @@
+package genie
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrFlagUnknown      = Error("unknown flag")
+	ErrFlagInvalid      = Error("invalid flag value")
+	ErrFlagMissingValue = Error("flag needs a value")
+	ErrFlagSyntax       = Error("bad flag syntax")
+)
+
+// FlagError is returned when the flags provided to a command could not be parsed.
+type FlagError struct {
+	Path        string //path of the command the flag was provided to
+	Flag        string //name of the offending flag, without dashes
+	Value       string //value provided for the flag, if any
+	Reason      string //why the value was invalid, if known
+	Suggestions []string
+	Err         error //one of the ErrFlag errors
+}
+
+func (e *FlagError) Error() string {
+	switch e.Err {
+	case ErrFlagUnknown:
+		return fmt.Sprintf("unknown flag %s%s", dashed(e.Flag), didYouMean(dashedAll(e.Suggestions), "%s"))
+	case ErrFlagMissingValue:
+		return fmt.Sprintf("flag %s needs a value", dashed(e.Flag))
+	case ErrFlagInvalid:
+		if e.Reason != "" {
+			return fmt.Sprintf("invalid value %q for flag %s: %s", e.Value, dashed(e.Flag), e.Reason)
+		}
+		return fmt.Sprintf("invalid value %q for flag %s", e.Value, dashed(e.Flag))
+	case ErrFlagSyntax:
+		return fmt.Sprintf("bad flag syntax: %s", e.Flag)
+	}
+
+	return fmt.Sprintf("%s: %s", e.Err, dashed(e.Flag))
+}
+
+func (e *FlagError) Unwrap() error {
+	return e.Err
+}
+
+func (e *FlagError) As(target interface{}) bool {
+	token := e.Flag
+	if e.Err != ErrFlagSyntax {
+		token = dashed(e.Flag)
+	}
+
+	return asUsageError(target, &UsageError{Path: e.Path, Token: token, Suggestions: dashedAll(e.Suggestions), Err: e})
+}
+
+var (
+	unknownFlagPattern     = regexp.MustCompile(`^flag provided but not defined: -(.*)$`)
+	missingValuePattern    = regexp.MustCompile(`^flag needs an argument: -(.*)$`)
+	invalidValuePattern    = regexp.MustCompile(`^invalid (?:boolean )?value ("(?:[^"\\]|\\.)*") for (?:flag )?-([^:]*): (.*)$`)
+	invalidBoolFlagPattern = regexp.MustCompile(`^invalid boolean flag ([^:]*): (.*)$`)
+	badFlagSyntaxPattern   = regexp.MustCompile(`^bad flag syntax: (.*)$`)
+)
+
+// newFlagError converts an error returned by flag.FlagSet.Parse of args in to a FlagError, flag.ErrHelp is returned
+// as is.
+func newFlagError(command *Command, args []string, err error) error {
+	if errors.Is(err, flag.ErrHelp) {
+		return err
+	}
+
+	msg := err.Error()
+	fe := &FlagError{Path: command.pathOrName(), Err: ErrFlagSyntax, Flag: msg}
+	if m := unknownFlagPattern.FindStringSubmatch(msg); m != nil {
+		fe.Flag, fe.Err = m[1], ErrFlagUnknown
+		fe.Value = flagArgValue(fe.Flag, args)
+		fe.Suggestions = command.suggestFlags(fe.Flag)
+	} else if m := missingValuePattern.FindStringSubmatch(msg); m != nil {
+		fe.Flag, fe.Err = m[1], ErrFlagMissingValue
+	} else if m := invalidValuePattern.FindStringSubmatch(msg); m != nil {
+		fe.Flag, fe.Reason, fe.Err = m[2], m[3], ErrFlagInvalid
+		if v, err := strconv.Unquote(m[1]); err == nil {
+			fe.Value = v
+		}
+	} else if m := invalidBoolFlagPattern.FindStringSubmatch(msg); m != nil {
+		fe.Flag, fe.Reason, fe.Err = m[1], m[2], ErrFlagInvalid
+		fe.Value = flagArgValue(fe.Flag, args)
+	} else if m := badFlagSyntaxPattern.FindStringSubmatch(msg); m != nil {
+		fe.Flag = m[1]
+	}
+
+	return fe
+}
+
+// flagArgValue returns the value provided with the named flag as -flag=value in args, if any.
+func flagArgValue(name string, args []string) string {
+	for _, arg := range beforeTerminator(args) {
+		if !isFlagArg(arg) {
+			continue
+		}
+		if n, hasValue := flagArgName(arg); n == name && hasValue {
+			return arg[strings.Index(arg, "=")+1:]
+		}
+	}
+
+	return ""
+}
+
+// suggestFlags returns the names of flags similar to name, secret flags are never suggested.
+func (c *Command) suggestFlags(name string) []string {
+	if c.Flags == nil {
+		return nil
+	}
+
+	var candidates []suggestion
+	c.Flags.VisitAll(func(f *flag.Flag) {
+		if c.flagIsSecret(f.Name) {
+			return
+		}
+		if d, ok := similar(name, f.Name); ok {
+			candidates = append(candidates, suggestion{name: f.Name, distance: d})
+		}
+	})
+
+	return sortSuggestions(candidates)
+}
+
+// dashed returns the flag name as it's displayed in usage, single character flags have a single dash.
+func dashed(name string) string {
+	if len(name) == 1 {
+		return "-" + name
+	}
+
+	return "--" + name
+}
+
+func dashedAll(names []string) []string {
+	d := make([]string, len(names))
+	for i, n := range names {
+		d[i] = dashed(n)
+	}
+
+	return d
+}
@@ -0,0 +1,139 @@
+package genie
+
+import (
+	"errors"
+	"flag"
+	"reflect"
+	"testing"
+)
+
+func TestFlagError_Error(t *testing.T) {
+	testCases := []struct {
+		name    string
+		subject *FlagError
+		want    string
+	}{
+		{
+			name:    "unknown",
+			subject: &FlagError{Flag: "tset", Err: ErrFlagUnknown},
+			want:    "unknown flag --tset",
+		},
+		{
+			name:    "unknown with suggestions",
+			subject: &FlagError{Flag: "tset", Suggestions: []string{"test", "t"}, Err: ErrFlagUnknown},
+			want:    "unknown flag --tset; did you mean --test or -t?",
+		},
+		{
+			name:    "missing value",
+			subject: &FlagError{Flag: "t", Err: ErrFlagMissingValue},
+			want:    "flag -t needs a value",
+		},
+		{
+			name:    "invalid",
+			subject: &FlagError{Flag: "count", Value: "three", Reason: "parse error", Err: ErrFlagInvalid},
+			want:    `invalid value "three" for flag --count: parse error`,
+		},
+		{
+			name:    "invalid without reason",
+			subject: &FlagError{Flag: "count", Value: "three", Err: ErrFlagInvalid},
+			want:    `invalid value "three" for flag --count`,
+		},
+		{
+			name:    "syntax",
+			subject: &FlagError{Flag: "---count", Err: ErrFlagSyntax},
+			want:    "bad flag syntax: ---count",
+		},
+		{
+			name:    "other",
+			subject: &FlagError{Flag: "count", Err: errors.New("oops")},
+			want:    "oops: --count",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.subject.Error()
+			if got != tc.want {
+				t.Errorf("want %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func Test_newFlagError(t *testing.T) {
+	testCases := []struct {
+		name string
+		args []string
+		want *FlagError
+	}{
+		{
+			name: "unknown",
+			args: []string{"--tset", "heyo"},
+			want: &FlagError{Path: "wish", Flag: "tset", Suggestions: []string{"test"}, Err: ErrFlagUnknown},
+		},
+		{
+			name: "unknown with value",
+			args: []string{"--tset=heyo"},
+			want: &FlagError{Path: "wish", Flag: "tset", Value: "heyo", Suggestions: []string{"test"}, Err: ErrFlagUnknown},
+		},
+		{
+			name: "missing value",
+			args: []string{"--count"},
+			want: &FlagError{Path: "wish", Flag: "count", Err: ErrFlagMissingValue},
+		},
+		{
+			name: "invalid value",
+			args: []string{"--count", "three"},
+			want: &FlagError{Path: "wish", Flag: "count", Value: "three", Reason: "parse error", Err: ErrFlagInvalid},
+		},
+		{
+			name: "invalid boolean value",
+			args: []string{"-v=maybe"},
+			want: &FlagError{Path: "wish", Flag: "v", Value: "maybe", Reason: "parse error", Err: ErrFlagInvalid},
+		},
+		{
+			name: "bad syntax",
+			args: []string{"---count"},
+			want: &FlagError{Path: "wish", Flag: "---count", Err: ErrFlagSyntax},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			subject := NewCommand("wish", true)
+			subject.Flags.String("test", "", "the test flag")
+			subject.Flags.String("t", "", "the test flag")
+			subject.Flags.Int("count", 0, "the count")
+			subject.Flags.Bool("v", false, "verbose")
+			subject.Flags.String("tester", "", "shhh")
+			subject.SecretFlag("tester")
+			subject.Run = func(command *Command) error {
+				return nil
+			}
+
+			got := subject.run(tc.args)
+			var flagErr *FlagError
+			if !errors.As(got, &flagErr) {
+				t.Fatalf("want *FlagError, got %T", got)
+			}
+			if !reflect.DeepEqual(flagErr, tc.want) {
+				t.Errorf("want %#v, got %#v", tc.want, flagErr)
+			}
+			if !errors.Is(got, tc.want.Err) {
+				t.Errorf("want %s, got %s", tc.want.Err, got)
+			}
+		})
+	}
+
+	t.Run("validate help is returned as is", func(t *testing.T) {
+		subject := NewCommand("wish", true)
+		subject.Run = func(command *Command) error {
+			return nil
+		}
+
+		got := subject.run([]string{"-h"})
+		if got != flag.ErrHelp {
+			t.Errorf("want %s, got %s", flag.ErrHelp, got)
+		}
+	})
+}
@@ -0,0 +1,143 @@
+package genie
+
+import (
+	"flag"
+	"strings"
+)
+
+// FlagSyntax is the syntax used to parse a command's flags.
+type FlagSyntax int
+
+const (
+	FlagSyntaxDefault FlagSyntax = iota //the Lamp's syntax for commands, the Go syntax for a Lamp
+	FlagSyntaxGo                        //the flag package's syntax, -flag and --flag are the same
+	FlagSyntaxGNU                       //GNU/POSIX syntax, see FlagSyntaxGNU below
+)
+
+// With FlagSyntaxGNU single letter flags use a single dash and may be clustered (-abc is -a -b -c), with the value of
+// the last flag in a cluster attached or following (-ovalue, -o value). Longer flags use two dashes, with the value
+// after "=" or following (--output=value, --output value). Boolean flags can be negated (--no-verbose), and flags given
+// a value with FlagNoValue can be provided without one. The flags are still defined with, and parsed by, the command's
+// flag.FlagSet, so existing commands keep working.
+
+// FlagNoValue sets the value used when the named flag is provided without one using FlagSyntaxGNU, making the flag's
+// value optional. For example, with FlagNoValue("color", "always"), --color is --color=always. When provided without
+// "=" the next argument is never taken as the flag's value.
+func (c *Command) FlagNoValue(name, value string) {
+	if c.noValues == nil {
+		c.noValues = make(map[string]string)
+	}
+	c.noValues[name] = value
+}
+
+// noValue returns the value used when the named flag is provided without one, if the flag's value is optional.
+func (c *Command) noValue(name string) (string, bool) {
+	for cmd := c; cmd != nil; cmd = cmd.parent {
+		if value, ok := cmd.noValues[name]; ok {
+			return value, true
+		}
+	}
+
+	return "", false
+}
+
+// flagSyntax returns the syntax of the command's flags, with the Lamp's syntax used if the command has none set.
+func (c *Command) flagSyntax(l *Lamp) FlagSyntax {
+	if c.FlagSyntax != FlagSyntaxDefault {
+		return c.FlagSyntax
+	}
+
+	if l != nil && l.FlagSyntax != FlagSyntaxDefault {
+		return l.FlagSyntax
+	}
+
+	return FlagSyntaxGo
+}
+
+// translateGNU translates args using FlagSyntaxGNU into the flag package's syntax, stopping at the first argument that
+// is not a flag, or "--". The translation of already translated args is the same.
+func (c *Command) translateGNU(args []string) []string {
+	translated := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--" || !isFlagArg(args[i]) {
+			return append(translated, args[i:]...)
+		}
+
+		tokens, consumed := c.gnuTokens(c.Flags.Lookup, args, i)
+		translated = append(translated, tokens...)
+		i += consumed - 1
+	}
+
+	return translated
+}
+
+// gnuTokens translates the flag argument at args[i] using FlagSyntaxGNU into the flag package's syntax, returning the
+// translated tokens and the number of args consumed. Flags needing a value are always translated to --flag=value, and
+// boolean flags to --flag or --flag=value, so each token stands alone. Unknown flags are left for the flag package to
+// report.
+func (c *Command) gnuTokens(lookup func(name string) *flag.Flag, args []string, i int) ([]string, int) {
+	arg := args[i]
+	if strings.HasPrefix(arg, "--") {
+		name, hasValue := flagArgName(arg)
+		f := lookup(name)
+		if f == nil {
+			//--no-flag negates a boolean flag, as long as no-flag isn't a flag itself
+			if negated := lookup(strings.TrimPrefix(name, "no-")); strings.HasPrefix(name, "no-") && !hasValue && negated != nil && isBoolFlag(negated) {
+				return []string{"--" + negated.Name + "=false"}, 1
+			}
+			return []string{arg}, 1
+		}
+
+		return c.gnuValue(f, arg[2+len(name):], hasValue, args, i)
+	}
+
+	//a single dash is a cluster of single letter flags, the last of which may take a value
+	var tokens []string
+	cluster := arg[1:]
+	for j := 0; j < len(cluster); j++ {
+		name := cluster[j : j+1]
+		f := lookup(name)
+		if f == nil || len(f.Name) != 1 {
+			//-long is not --long, so only the unknown letter is left to be reported
+			return append(tokens, "-"+name), 1
+		}
+
+		if isBoolFlag(f) {
+			if j+1 < len(cluster) && cluster[j+1] == '=' {
+				return append(tokens, "-"+cluster[j:]), 1
+			}
+			tokens = append(tokens, "-"+name)
+			continue
+		}
+
+		value := cluster[j+1:]
+		value = strings.TrimPrefix(value, "=")
+		more, consumed := c.gnuValue(f, value, value != "", args, i)
+		return append(tokens, more...), consumed
+	}
+
+	return tokens, 1
+}
+
+// gnuValue returns the token for f with value, taking the next argument as the value if needed.
+func (c *Command) gnuValue(f *flag.Flag, value string, hasValue bool, args []string, i int) ([]string, int) {
+	token := dashed(f.Name)
+	if hasValue {
+		return []string{token + "=" + strings.TrimPrefix(value, "=")}, 1
+	}
+
+	if isBoolFlag(f) {
+		return []string{token}, 1
+	}
+
+	if noValue, ok := c.noValue(f.Name); ok {
+		return []string{token + "=" + noValue}, 1
+	}
+
+	if i+1 < len(args) {
+		return []string{token + "=" + args[i+1]}, 2
+	}
+
+	//left for the flag package to report the missing value
+	return []string{token}, 1
+}
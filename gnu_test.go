@@ -0,0 +1,164 @@
+package genie
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCommand_translateGNU(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"cluster", []string{"-av"}, "-a -v"},
+		{"cluster with attached value", []string{"-avofile"}, "-a -v -o=file"},
+		{"cluster with following value", []string{"-avo", "file", "arg"}, "-a -v -o=file arg"},
+		{"short with equals", []string{"-o=file"}, "-o=file"},
+		{"long with equals", []string{"--count=3"}, "--count=3"},
+		{"long with following value", []string{"--count", "3"}, "--count=3"},
+		{"negation", []string{"--no-loud"}, "--loud=false"},
+		{"optional value without value", []string{"--color", "arg"}, "--color=always arg"},
+		{"optional value with value", []string{"--color=auto"}, "--color=auto"},
+		{"single dash long", []string{"-loud"}, "-l"},
+		{"unknown long", []string{"--nope", "arg"}, "--nope arg"},
+		{"negation of non bool", []string{"--no-count"}, "--no-count"},
+		{"stops at argument", []string{"arg", "-av"}, "arg -av"},
+		{"stops at terminator", []string{"-a", "--", "-v"}, "-a -- -v"},
+		{"missing value", []string{"--count"}, "--count"},
+	}
+
+	for _, tt := range tests {
+		t.Run("validate "+tt.name, func(t *testing.T) {
+			subject := NewCommand("wish", true)
+			subject.Flags.Bool("a", false, "all")
+			subject.Flags.Bool("v", false, "verbose")
+			subject.Flags.Bool("loud", false, "loud")
+			subject.Flags.String("o", "", "output")
+			subject.Flags.String("color", "never", "color")
+			subject.Flags.Int("count", 0, "count")
+			subject.FlagNoValue("color", "always")
+
+			got := subject.translateGNU(tt.args)
+			if strings.Join(got, " ") != tt.want {
+				t.Errorf("want %s, got %s", tt.want, strings.Join(got, " "))
+			}
+
+			//translating again must not change anything
+			again := subject.translateGNU(got)
+			if strings.Join(again, " ") != tt.want {
+				t.Errorf("want %s, got %s", tt.want, strings.Join(again, " "))
+			}
+		})
+	}
+}
+
+func TestCommand_run_gnuSyntax(t *testing.T) {
+	t.Run("validate flags are bound", func(t *testing.T) {
+		all, verbose, loud := false, false, true
+		output, color := "", "never"
+		count := 0
+		subject := NewCommand("wish", true)
+		subject.FlagSyntax = FlagSyntaxGNU
+		subject.Flags.BoolVar(&all, "a", false, "all")
+		subject.Flags.BoolVar(&verbose, "v", false, "verbose")
+		subject.Flags.BoolVar(&loud, "loud", true, "loud")
+		subject.Flags.StringVar(&output, "o", "", "output")
+		subject.Flags.StringVar(&color, "color", "never", "color")
+		subject.Flags.IntVar(&count, "count", 0, "count")
+		subject.FlagNoValue("color", "always")
+		subject.Run = func(command *Command) error {
+			return nil
+		}
+
+		err := subject.run([]string{"-avo", "out.txt", "--count", "3", "--color", "--no-loud", "arg"})
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		if !all || !verbose || output != "out.txt" || count != 3 || color != "always" || loud {
+			t.Errorf("want flags bound, got %v %v %s %d %s %v", all, verbose, output, count, color, loud)
+		}
+
+		if got := subject.Flags.Args(); len(got) != 1 || got[0] != "arg" {
+			t.Errorf("want [arg], got %v", got)
+		}
+
+		if !subject.FlagWasProvided("o") || !subject.FlagWasProvided("loud") {
+			t.Errorf("want provided")
+		}
+	})
+
+	t.Run("validate single dash long flag is an error", func(t *testing.T) {
+		subject := NewCommand("wish", true)
+		subject.FlagSyntax = FlagSyntaxGNU
+		subject.Flags.Bool("loud", false, "loud")
+		subject.Run = func(command *Command) error {
+			return nil
+		}
+
+		err := subject.run([]string{"-loud"})
+		var got *FlagError
+		if !errors.As(err, &got) || got.Flag != "l" || !errors.Is(err, ErrFlagUnknown) {
+			t.Errorf("want unknown flag -l, got %v", err)
+		}
+	})
+
+	t.Run("validate go syntax is unchanged by default", func(t *testing.T) {
+		loud := false
+		count := 0
+		subject := NewCommand("wish", true)
+		subject.Flags.BoolVar(&loud, "loud", false, "loud")
+		subject.Flags.IntVar(&count, "count", 0, "count")
+		subject.Run = func(command *Command) error {
+			return nil
+		}
+
+		err := subject.run([]string{"-loud", "-count", "3"})
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		if !loud || count != 3 {
+			t.Errorf("want flags bound, got %v %d", loud, count)
+		}
+	})
+}
+
+func TestLamp_ExecuteWith_gnuSyntax(t *testing.T) {
+	var global, all, verbose bool
+	var output string
+	var count int
+	subject := NewLamp("magic", "0.0.0", true)
+	subject.FlagSyntax = FlagSyntaxGNU
+	subject.RootCommand.PersistentFlags.BoolVar(&global, "V", false, "verbose")
+	wish := NewCommand("wish", true)
+	wish.Flags.BoolVar(&all, "a", false, "all")
+	wish.Flags.BoolVar(&verbose, "v", false, "verbose")
+	wish.Flags.StringVar(&output, "o", "", "output")
+	wish.Flags.IntVar(&count, "count", 0, "count")
+	wish.Run = func(command *Command) error {
+		return nil
+	}
+	subject.RootCommand.SubCommands = []*Command{wish}
+	subject.SetWriters(bytes.NewBufferString(""), bytes.NewBufferString(""))
+
+	got, err := subject.ExecuteWith([]string{"magic", "-V", "wish", "-avVo", "out.txt", "--count=3", "arg"})
+	if err != nil {
+		t.Fatalf("want nil, got %s", err)
+	}
+
+	if got != wish {
+		t.Errorf("want %s, got %s", wish.Name, got.Name)
+	}
+
+	if !global || !all || !verbose || output != "out.txt" || count != 3 {
+		t.Errorf("want flags bound, got %v %v %v %s %d", global, all, verbose, output, count)
+	}
+
+	if wish.FlagsAndArgs() != "V true a true count 3 o out.txt v true arg" {
+		t.Errorf("want flags and args, got %s", wish.FlagsAndArgs())
+	}
+}
@@ -0,0 +1,47 @@
+package genie
+
+// PostRunFunc is called after Run with the error Run returned, if any. The error returned by the PostRunFunc replaces
+// the error from Run, so return err unchanged unless you mean to handle or wrap it.
+type PostRunFunc func(command *Command, err error) error
+
+// runPreHooks calls the PersistentPreRun of the command and each of its parents from the root down, followed by the
+// command's PreRun. The executing command is always the one passed to the hooks. If a hook returns an error no further
+// hooks are called.
+func (c *Command) runPreHooks() error {
+	var chain []*Command
+	for cmd := c; cmd != nil; cmd = cmd.parent {
+		chain = append(chain, cmd)
+	}
+
+	for i := len(chain) - 1; i >= 0; i-- {
+		if chain[i].PersistentPreRun == nil {
+			continue
+		}
+
+		if err := chain[i].PersistentPreRun(c); err != nil {
+			return err
+		}
+	}
+
+	if c.PreRun != nil {
+		return c.PreRun(c)
+	}
+
+	return nil
+}
+
+// runPostHooks calls the command's PostRun, followed by the PersistentPostRun of the command and each of its parents
+// up to the root. Every hook is called, each receiving the error returned by the hook before it, starting with err.
+func (c *Command) runPostHooks(err error) error {
+	if c.PostRun != nil {
+		err = c.PostRun(c, err)
+	}
+
+	for cmd := c; cmd != nil; cmd = cmd.parent {
+		if cmd.PersistentPostRun != nil {
+			err = cmd.PersistentPostRun(c, err)
+		}
+	}
+
+	return err
+}
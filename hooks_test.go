@@ -0,0 +1,169 @@
+package genie
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCommand_hooks(t *testing.T) {
+	record := func(calls *[]string, name string) RunFunc {
+		return func(command *Command) error {
+			*calls = append(*calls, name+":"+command.Name)
+			return nil
+		}
+	}
+	recordPost := func(calls *[]string, name string) PostRunFunc {
+		return func(command *Command, err error) error {
+			*calls = append(*calls, name+":"+command.Name)
+			return err
+		}
+	}
+
+	t.Run("validate hooks run root to leaf before and leaf to root after", func(t *testing.T) {
+		want := "root-pre:sub wish-pre:sub pre:sub run:sub post:sub wish-post:sub root-post:sub"
+		var calls []string
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.PersistentPreRun = record(&calls, "root-pre")
+		subject.RootCommand.PersistentPostRun = recordPost(&calls, "root-post")
+		wish := NewCommand("wish", true)
+		wish.PersistentPreRun = record(&calls, "wish-pre")
+		wish.PersistentPostRun = recordPost(&calls, "wish-post")
+		sub := NewCommand("sub", true)
+		sub.PreRun = record(&calls, "pre")
+		sub.Run = record(&calls, "run")
+		sub.PostRun = recordPost(&calls, "post")
+		wish.SubCommands = []*Command{sub}
+		subject.RootCommand.SubCommands = []*Command{wish}
+
+		_, err := subject.ExecuteWith([]string{"magic", "wish", "sub"})
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		got := strings.Join(calls, " ")
+		if got != want {
+			t.Errorf("want %s, got %s", want, got)
+		}
+	})
+
+	t.Run("validate post hooks run and receive the run error", func(t *testing.T) {
+		want := errors.New("run failed")
+		var calls []string
+		var received error
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.PersistentPostRun = recordPost(&calls, "root-post")
+		wish := NewCommand("wish", true)
+		wish.PersistentPostRun = recordPost(&calls, "wish-post")
+		sub := NewCommand("sub", true)
+		sub.Run = func(command *Command) error {
+			return want
+		}
+		sub.PostRun = func(command *Command, err error) error {
+			received = err
+			return err
+		}
+		wish.SubCommands = []*Command{sub}
+		subject.RootCommand.SubCommands = []*Command{wish}
+
+		_, got := subject.ExecuteWith([]string{"magic", "wish", "sub"})
+		if got != want {
+			t.Errorf("want %s, got %s", want, got)
+		}
+
+		if received != want {
+			t.Errorf("want %s, got %s", want, received)
+		}
+
+		if strings.Join(calls, " ") != "wish-post:sub root-post:sub" {
+			t.Errorf("want persistent post hooks to run, got %v", calls)
+		}
+	})
+
+	t.Run("validate post hook can replace the run error", func(t *testing.T) {
+		subject := NewLamp("magic", "0.0.0", true)
+		wish := NewCommand("wish", true)
+		wish.Run = func(command *Command) error {
+			return errors.New("run failed")
+		}
+		wish.PostRun = func(command *Command, err error) error {
+			return nil
+		}
+		subject.RootCommand.SubCommands = []*Command{wish}
+
+		_, got := subject.ExecuteWith([]string{"magic", "wish"})
+		if got != nil {
+			t.Errorf("want nil, got %s", got)
+		}
+	})
+
+	t.Run("validate pre hook error stops execution", func(t *testing.T) {
+		want := "root-pre:sub"
+		wantErr := errors.New("not authenticated")
+		var calls []string
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.PersistentPreRun = record(&calls, "root-pre")
+		wish := NewCommand("wish", true)
+		wish.PersistentPreRun = func(command *Command) error {
+			return wantErr
+		}
+		sub := NewCommand("sub", true)
+		sub.PreRun = record(&calls, "pre")
+		sub.Run = record(&calls, "run")
+		wish.SubCommands = []*Command{sub}
+		subject.RootCommand.SubCommands = []*Command{wish}
+
+		_, err := subject.ExecuteWith([]string{"magic", "wish", "sub"})
+		if err != wantErr {
+			t.Errorf("want %s, got %s", wantErr, err)
+		}
+
+		got := strings.Join(calls, " ")
+		if got != want {
+			t.Errorf("want %s, got %s", want, got)
+		}
+	})
+
+	t.Run("validate hooks are not run when check fails", func(t *testing.T) {
+		var calls []string
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.PersistentPreRun = record(&calls, "root-pre")
+		subject.RootCommand.PersistentPostRun = recordPost(&calls, "root-post")
+		wish := NewCommand("wish", true)
+		wish.PreRun = record(&calls, "pre")
+		wish.Run = record(&calls, "run")
+		wish.PostRun = recordPost(&calls, "post")
+		wish.Check = func(command *Command) error {
+			return errors.New("bad")
+		}
+		subject.RootCommand.SubCommands = []*Command{wish}
+
+		_, err := subject.ExecuteWith([]string{"magic", "wish"})
+		if err == nil {
+			t.Errorf("want error, got nil")
+		}
+
+		if len(calls) != 0 {
+			t.Errorf("want no hooks, got %v", calls)
+		}
+	})
+
+	t.Run("validate hooks of the command run without a lamp", func(t *testing.T) {
+		want := "pre:sub run:sub post:sub"
+		var calls []string
+		subject := NewCommand("sub", true)
+		subject.PreRun = record(&calls, "pre")
+		subject.Run = record(&calls, "run")
+		subject.PostRun = recordPost(&calls, "post")
+
+		err := subject.run([]string{})
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		got := strings.Join(calls, " ")
+		if got != want {
+			t.Errorf("want %s, got %s", want, got)
+		}
+	})
+}
@@ -0,0 +1,161 @@
+package genie
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	ErrInputTooLarge = Error("input too large")
+	ErrInputInvalid  = Error("invalid input")
+)
+
+// MaxLineSize is the longest line, in bytes, the piped-input helpers will read.
+var MaxLineSize = 1024 * 1024
+
+// InputError is returned by the piped-input helpers when the input can't be read or decoded.
+type InputError struct {
+	Path string //path of the command reading the input
+	Line int    //the line the error occurred on, 0 if not line based
+	Err  error
+}
+
+func (e *InputError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%q input line %d: %s", e.Path, e.Line, e.Err)
+	}
+
+	return fmt.Sprintf("%q input: %s", e.Path, e.Err)
+}
+
+func (e *InputError) Unwrap() error {
+	return e.Err
+}
+
+// ReadLines calls fn with each line of the command's input, without the line ending. Reading stops at the end of the
+// input, when fn returns an error, or when the command's context is done, which is checked before each line.
+func (c *Command) ReadLines(fn func(line string) error) error {
+	scanner := bufio.NewScanner(c.stdin())
+	size := 4096
+	if MaxLineSize < size {
+		size = MaxLineSize
+	}
+	scanner.Buffer(make([]byte, 0, size), MaxLineSize)
+
+	n := 0
+	for scanner.Scan() {
+		n++
+		if err := c.Context().Err(); err != nil {
+			return err
+		}
+
+		if err := fn(scanner.Text()); err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			err = fmt.Errorf("%w: line longer than %d bytes", ErrInputTooLarge, MaxLineSize)
+		}
+		return &InputError{Path: c.pathOrName(), Line: n + 1, Err: err}
+	}
+
+	return c.Context().Err()
+}
+
+// Lines returns all lines of the command's input.
+func (c *Command) Lines() ([]string, error) {
+	var lines []string
+	err := c.ReadLines(func(line string) error {
+		lines = append(lines, line)
+		return nil
+	})
+
+	return lines, err
+}
+
+// InputReader returns a reader of the command's input that fails with an InputError wrapping ErrInputTooLarge once
+// more than limit bytes are read. A limit <= 0 means no limit. The command's context is checked before each read.
+func (c *Command) InputReader(limit int64) io.Reader {
+	return &inputReader{command: c, in: c.stdin(), limit: limit}
+}
+
+// ReadBytes returns the command's input, an InputError wrapping ErrInputTooLarge is returned if the input is larger
+// than limit bytes. A limit <= 0 means no limit. The command's context is checked between reads.
+func (c *Command) ReadBytes(limit int64) ([]byte, error) {
+	b, err := io.ReadAll(c.InputReader(limit))
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+type inputReader struct {
+	command *Command
+	in      io.Reader
+	limit   int64
+	read    int64
+}
+
+func (r *inputReader) Read(p []byte) (int, error) {
+	if err := r.command.Context().Err(); err != nil {
+		return 0, err
+	}
+
+	//read one byte past the limit, so input of exactly limit bytes isn't too large
+	if r.limit > 0 && int64(len(p)) > r.limit-r.read+1 {
+		p = p[:r.limit-r.read+1]
+	}
+
+	n, err := r.in.Read(p)
+	r.read += int64(n)
+	if r.limit > 0 && r.read > r.limit {
+		over := int(r.read - r.limit)
+		r.read = r.limit
+		return n - over, &InputError{Path: r.command.pathOrName(), Err: fmt.Errorf("%w: more than %d bytes", ErrInputTooLarge, r.limit)}
+	}
+
+	if err != nil && err != io.EOF {
+		err = &InputError{Path: r.command.pathOrName(), Err: err}
+	}
+
+	return n, err
+}
+
+// DecodeJSON decodes the command's input as a single JSON value into v.
+func (c *Command) DecodeJSON(v interface{}) error {
+	if err := c.Context().Err(); err != nil {
+		return err
+	}
+
+	if err := json.NewDecoder(c.stdin()).Decode(v); err != nil {
+		return &InputError{Path: c.pathOrName(), Err: fmt.Errorf("%w: %s", ErrInputInvalid, err)}
+	}
+
+	return nil
+}
+
+// ReadJSONLines calls fn with each line of the command's input as a JSON record, blank lines are skipped. Decode the
+// record with json.Unmarshal. An InputError wrapping ErrInputInvalid is returned for a line that isn't valid JSON.
+func (c *Command) ReadJSONLines(fn func(record json.RawMessage) error) error {
+	n := 0
+	return c.ReadLines(func(line string) error {
+		n++
+		record := bytes.TrimSpace([]byte(line))
+		if len(record) == 0 {
+			return nil
+		}
+
+		if !json.Valid(record) {
+			return &InputError{Path: c.pathOrName(), Line: n, Err: ErrInputInvalid}
+		}
+
+		return fn(json.RawMessage(record))
+	})
+}
@@ -0,0 +1,258 @@
+package genie
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestCommand_ReadLines(t *testing.T) {
+	t.Run("validate lines are read", func(t *testing.T) {
+		want := "one|two|three"
+		subject := NewCommand("wish", true)
+		subject.SetIn(strings.NewReader("one\ntwo\r\nthree"))
+
+		got, err := subject.Lines()
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		if strings.Join(got, "|") != want {
+			t.Errorf("want %s, got %s", want, strings.Join(got, "|"))
+		}
+	})
+
+	t.Run("validate fn error stops reading", func(t *testing.T) {
+		want := errors.New("stop")
+		subject := NewCommand("wish", true)
+		subject.SetIn(strings.NewReader("one\ntwo\nthree"))
+		var lines []string
+
+		got := subject.ReadLines(func(line string) error {
+			lines = append(lines, line)
+			return want
+		})
+		if got != want {
+			t.Errorf("want %s, got %s", want, got)
+		}
+
+		if len(lines) != 1 {
+			t.Errorf("want 1, got %d", len(lines))
+		}
+	})
+
+	t.Run("validate cancelled context stops reading", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		subject := NewCommand("wish", true)
+		subject.SetIn(strings.NewReader("one\ntwo\nthree"))
+		subject.SetContext(ctx)
+		var lines []string
+
+		got := subject.ReadLines(func(line string) error {
+			lines = append(lines, line)
+			cancel()
+			return nil
+		})
+		if !errors.Is(got, context.Canceled) {
+			t.Errorf("want %s, got %s", context.Canceled, got)
+		}
+
+		if len(lines) != 1 {
+			t.Errorf("want 1, got %d", len(lines))
+		}
+	})
+
+	t.Run("validate line too long", func(t *testing.T) {
+		old := MaxLineSize
+		defer func() { MaxLineSize = old }()
+		MaxLineSize = 8
+		subject := NewCommand("wish", true)
+		subject.SetIn(strings.NewReader("one\nthis line is too long\n"))
+
+		_, got := subject.Lines()
+		var inputErr *InputError
+		if !errors.As(got, &inputErr) || inputErr.Line != 2 {
+			t.Fatalf("want input error on line 2, got %v", got)
+		}
+
+		if !errors.Is(got, ErrInputTooLarge) {
+			t.Errorf("want %s, got %s", ErrInputTooLarge, got)
+		}
+	})
+}
+
+func TestCommand_InputReader(t *testing.T) {
+	t.Run("validate input is streamed", func(t *testing.T) {
+		want := "heyo\nthere"
+		subject := NewCommand("wish", true)
+		subject.SetIn(strings.NewReader(want))
+
+		var b bytes.Buffer
+		_, err := io.Copy(&b, subject.InputReader(int64(len(want))))
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		if b.String() != want {
+			t.Errorf("want %s, got %s", want, b.String())
+		}
+	})
+
+	t.Run("validate input up to the limit is read before the error", func(t *testing.T) {
+		want := "hey"
+		subject := NewCommand("wish", true)
+		subject.SetIn(strings.NewReader("heyo there"))
+
+		var b bytes.Buffer
+		_, got := io.Copy(&b, subject.InputReader(3))
+		if !errors.Is(got, ErrInputTooLarge) {
+			t.Errorf("want %s, got %s", ErrInputTooLarge, got)
+		}
+
+		if b.String() != want {
+			t.Errorf("want %s, got %s", want, b.String())
+		}
+	})
+
+	t.Run("validate cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		subject := NewCommand("wish", true)
+		subject.SetIn(strings.NewReader("heyo"))
+		subject.SetContext(ctx)
+
+		_, got := subject.InputReader(0).Read(make([]byte, 4))
+		if !errors.Is(got, context.Canceled) {
+			t.Errorf("want %s, got %s", context.Canceled, got)
+		}
+	})
+}
+
+func TestCommand_ReadBytes(t *testing.T) {
+	t.Run("validate bytes are read", func(t *testing.T) {
+		want := "heyo\nthere"
+		subject := NewCommand("wish", true)
+		subject.SetIn(strings.NewReader(want))
+
+		got, err := subject.ReadBytes(0)
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		if string(got) != want {
+			t.Errorf("want %s, got %s", want, got)
+		}
+	})
+
+	t.Run("validate input at limit is read", func(t *testing.T) {
+		subject := NewCommand("wish", true)
+		subject.SetIn(strings.NewReader("heyo"))
+
+		_, err := subject.ReadBytes(4)
+		if err != nil {
+			t.Errorf("want nil, got %s", err)
+		}
+	})
+
+	t.Run("validate input over limit errors", func(t *testing.T) {
+		want := `"wish" input: input too large: more than 3 bytes`
+		subject := NewCommand("wish", true)
+		subject.SetIn(strings.NewReader("heyo"))
+
+		_, got := subject.ReadBytes(3)
+		if !errors.Is(got, ErrInputTooLarge) {
+			t.Errorf("want %s, got %s", ErrInputTooLarge, got)
+		}
+
+		if got.Error() != want {
+			t.Errorf("want %s, got %s", want, got)
+		}
+	})
+
+	t.Run("validate cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		subject := NewCommand("wish", true)
+		subject.SetIn(strings.NewReader("heyo"))
+		subject.SetContext(ctx)
+
+		_, got := subject.ReadBytes(0)
+		if !errors.Is(got, context.Canceled) {
+			t.Errorf("want %s, got %s", context.Canceled, got)
+		}
+	})
+}
+
+func TestCommand_DecodeJSON(t *testing.T) {
+	t.Run("validate json is decoded", func(t *testing.T) {
+		subject := NewCommand("wish", true)
+		subject.SetIn(strings.NewReader(`{"wish": "heyo"}`))
+		var got struct{ Wish string }
+
+		err := subject.DecodeJSON(&got)
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		if got.Wish != "heyo" {
+			t.Errorf("want heyo, got %s", got.Wish)
+		}
+	})
+
+	t.Run("validate invalid json errors", func(t *testing.T) {
+		subject := NewCommand("wish", true)
+		subject.SetIn(strings.NewReader(`{"wish": `))
+		var got struct{ Wish string }
+
+		err := subject.DecodeJSON(&got)
+		if !errors.Is(err, ErrInputInvalid) {
+			t.Errorf("want %s, got %s", ErrInputInvalid, err)
+		}
+	})
+}
+
+func TestCommand_ReadJSONLines(t *testing.T) {
+	t.Run("validate json lines are read", func(t *testing.T) {
+		want := "one|two"
+		subject := NewCommand("wish", true)
+		subject.SetIn(strings.NewReader("{\"wish\": \"one\"}\n\n{\"wish\": \"two\"}\n"))
+		var got []string
+
+		err := subject.ReadJSONLines(func(record json.RawMessage) error {
+			var v struct{ Wish string }
+			if err := json.Unmarshal(record, &v); err != nil {
+				return err
+			}
+			got = append(got, v.Wish)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		if strings.Join(got, "|") != want {
+			t.Errorf("want %s, got %s", want, strings.Join(got, "|"))
+		}
+	})
+
+	t.Run("validate invalid line errors", func(t *testing.T) {
+		want := `"wish" input line 3: invalid input`
+		subject := NewCommand("wish", true)
+		subject.SetIn(strings.NewReader("{\"wish\": \"one\"}\n\n{\"wish\": \n"))
+
+		got := subject.ReadJSONLines(func(record json.RawMessage) error {
+			return nil
+		})
+		if !errors.Is(got, ErrInputInvalid) {
+			t.Errorf("want %s, got %s", ErrInputInvalid, got)
+		}
+
+		if got.Error() != want {
+			t.Errorf("want %s, got %s", want, got)
+		}
+	})
+}
@@ -1,45 +1,49 @@
 package genie
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"io"
 	"os"
+	"sort"
 	"strings"
 )
 
-type Error string
-
-func (e Error) Error() string { return string(e) }
-
-var (
-	ErrNoOp                = Error("noop")
-	ErrNoArgs              = Error("arguments not provided")
-	ErrCommandDepthInvalid = Error("invalid command depth")
-	ErrCommandNotFound     = Error("command not found")
-	ErrCommandNotRunnable  = Error("command not runnable")
-)
-
 // Lamp is a very simple representation of a Command Line Interface or any interface with commands.
 type Lamp struct {
 	Name            string
 	RootCommand     *Command
+	In              io.Reader
 	Out             io.Writer
 	Err             io.Writer
 	Version         string
 	SilenceFlags    bool
-	MaxCommandDepth int
+	MaxCommandDepth int          //optional limit on command depth, counting the interface name, 0 means no limit
+	PrefixMatching  bool         //if true any unambiguous prefix of a command name or alias will find the command
+	AutomaticEnv    bool         //if true every flag is bound to an environment variable named by EnvName
+	ConfigFile      string       //optional config file flag values are loaded from, see SetConfigFile
+	FlagSyntax      FlagSyntax   //the syntax used to parse flags of commands that don't set their own
+	HandleSignals   bool         //if true SIGINT/SIGTERM cancel the executing command's context, a second signal forces exit
+	Exit            func(int)    //called to exit the process, defaults to os.Exit when nil
+	UsagePolicy     UsagePolicy  //controls when Main shows usage after an error
+	Middleware      []Middleware //wraps the execution of every command, outside of any command middleware
+	RecoverPanics   bool         //if true a panic during execution is returned as a PanicError instead of crashing
+	CrashReport     string       //optional file the full crash report is written to when a panic is recovered
+	configFlag      flag.Value   //the value of the --config flag added by SetConfigFile, if it was added
 }
 
 // NewLamp returns a Lamp with sensible defaults.
 func NewLamp(name, version string, silenceFlags bool) *Lamp {
 	return &Lamp{
-		Name:            name,
-		RootCommand:     NewCommand(name, silenceFlags),
-		Out:             os.Stdout,
-		Err:             os.Stderr,
-		Version:         version,
-		SilenceFlags:    silenceFlags,
-		MaxCommandDepth: 3,
+		Name:         name,
+		RootCommand:  NewCommand(name, silenceFlags),
+		In:           os.Stdin,
+		Out:          os.Stdout,
+		Err:          os.Stderr,
+		Version:      version,
+		SilenceFlags: silenceFlags,
+		Exit:         os.Exit,
 	}
 }
 
@@ -57,6 +61,18 @@ func (l *Lamp) SetWriters(o, e io.Writer) {
 	}
 }
 
+// SetReader will set the in reader on the interface and all commands, along with the piped detector used to decide if
+// the reader is piped. If the reader is nil it will not change the current reader, if the detector is nil commands will
+// use DefaultPipedDetector.
+func (l *Lamp) SetReader(i io.Reader, piped PipedDetector) {
+	if i != nil {
+		l.In = i
+		l.RootCommand.SetIn(i)
+	}
+
+	l.RootCommand.SetPiped(piped)
+}
+
 // Grant will execute the Lamp with os.Args as the provided arguments, returns the command executed if found.
 func (l *Lamp) Grant() (*Command, error) {
 	return l.Execute()
@@ -67,13 +83,40 @@ func (l *Lamp) GrantWith(args []string) (*Command, error) {
 	return l.ExecuteWith(args)
 }
 
+// GrantContext executes the Lamp with the provided context and arguments, returns the command executed if found.
+func (l *Lamp) GrantContext(ctx context.Context, args []string) (*Command, error) {
+	return l.ExecuteContext(ctx, args)
+}
+
 // Execute will execute the Lamp with os.Args as the provided arguments, returns the command executed if found.
 func (l *Lamp) Execute() (*Command, error) {
 	return l.ExecuteWith(os.Args)
 }
 
 // ExecuteWith executes the Lamp with the provided arguments, returns the command executed if found.
-func (l *Lamp) ExecuteWith(args []string) (*Command, error) { //all: os.Args() = lamp command command -flag value -flag2 value2 arg1 arg2
+func (l *Lamp) ExecuteWith(args []string) (*Command, error) {
+	return l.ExecuteContext(context.Background(), args)
+}
+
+// ExecuteContext executes the Lamp with the provided context and arguments, returns the command executed if found.
+// The context is available to the executed command's PipedIn, Check, and Run via Command.Context, and cancelling it
+// will stop the command before the next of those is called.
+func (l *Lamp) ExecuteContext(ctx context.Context, args []string) (*Command, error) { //all: os.Args() = lamp command command -flag value -flag2 value2 arg1 arg2
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	//every execution gets its own set of cleanups, which are run once the executed command returns
+	stack := &cleanups{}
+	defer stack.run()
+	ctx = context.WithValue(ctx, cleanupsKey{}, stack)
+
+	if l.HandleSignals {
+		var stop func()
+		ctx, stop = l.handleSignals(ctx, stack)
+		defer stop()
+	}
+
 	//if we have no root command there is nothing we can do
 	if l.RootCommand == nil {
 		return nil, ErrNoOp
@@ -95,60 +138,34 @@ func (l *Lamp) ExecuteWith(args []string) (*Command, error) { //all: os.Args() =
 		args[0] = l.Name
 	}
 
-	//just the lamp was provided so run root command
-	if len(args) == 1 {
-		return l.RootCommand, l.RootCommand.run([]string{})
+	command, commandArgs, err := l.resolve(args[1:])
+	if err != nil {
+		return command, err
 	}
 
-	//------------------------------------------------------------------------
-	//at this point there are more than enough args to check for flags etc....
-	//------------------------------------------------------------------------
-
-	flagStart, hasFlags := l.hasFlags(args)
-
-	//it's ok to not have flags, but if we do we assume a little structure
-	if hasFlags {
-		if flagStart > l.MaxCommandDepth {
-			return nil, ErrCommandDepthInvalid
-		}
-
-		switch flagStart {
-		case 1:
-			if askedForVersion(args) {
-				if l.Out != nil {
-					_, _ = fmt.Fprintln(l.Out, l.Version)
-				}
-				return l.RootCommand, nil
-			}
-			//calling interface
-			return l.RootCommand, l.RootCommand.run(args[flagStart:])
-		default:
-			command, found, _ := l.searchPathForCommand(args[1:flagStart], false)
-			if !found {
-				return nil, ErrCommandNotFound
-			}
+	if err := l.validateDepth(command); err != nil {
+		return nil, err
+	}
 
-			command.root = false
-			return command, command.run(args[flagStart:])
+	if command == l.RootCommand && askedForVersion(commandArgs) {
+		if l.Out != nil {
+			_, _ = fmt.Fprintln(l.Out, l.Version)
 		}
+		return l.RootCommand, nil
 	}
 
-	//----------------------------------------------------------------------------------
-	//no flags were provided, so we run things a little differently, with less structure
-	//----------------------------------------------------------------------------------
+	command.root = command == l.RootCommand
+	command.lamp = l
+	return command, command.runContext(ctx, commandArgs)
+}
 
-	//we may have a command provided
-	command, found, position := l.searchPathForCommand(args[1:], true)
-	if found {
-		if position+2 > l.MaxCommandDepth {
-			return nil, ErrCommandDepthInvalid
-		}
-		command.root = false
-		return command, command.run(args[position+2:])
+// validateDepth returns an error if MaxCommandDepth is set and the command is deeper than allowed.
+func (l *Lamp) validateDepth(command *Command) error {
+	if l.MaxCommandDepth > 0 && command.depth+1 > l.MaxCommandDepth {
+		return &UsageError{Path: command.pathOrName(), Token: command.Name, Err: ErrCommandDepthInvalid}
 	}
 
-	//no command or subcommand found so run root
-	return l.RootCommand, l.RootCommand.run(args[1:])
+	return nil
 }
 
 // TraverseCommands visits each command and its subcommands, and calls do with each command.
@@ -168,17 +185,6 @@ func traverse(c *Command, do func(command *Command)) {
 	}
 }
 
-// returns true if flags were found, and the start position of the first flag
-func (l *Lamp) hasFlags(args []string) (int, bool) {
-	for i, flag := range args {
-		if strings.HasPrefix(flag, "-") {
-			return i, true
-		}
-	}
-
-	return -1, false
-}
-
 // path should not contain the interface name
 // if partialAllowed == false, path should only contain commands, no flags/args (e.g. command subcommand)
 // if partialAllowed == true, path can contain trailing flags/args as it will return last command found if any (e.g. command subcommand -flag value -flag2 value2 arg1 arg2)
@@ -188,7 +194,7 @@ func (l *Lamp) searchPathForCommand(path []string, partialAllowed bool) (*Comman
 	lastFoundAt := -1
 	for i, pathPart := range path {
 		if i == 0 {
-			temp, found := l.RootCommand.findSubCommand(pathPart)
+			temp, found, _ := l.findSubCommand(l.RootCommand, pathPart)
 			//if very first element results in not found it makes no sense to continue at all
 			if !found {
 				return nil, false, -1
@@ -197,7 +203,7 @@ func (l *Lamp) searchPathForCommand(path []string, partialAllowed bool) (*Comman
 			lastFoundResult = found
 			lastFoundAt = i
 		} else {
-			temp, found := lastFoundCommand.findSubCommand(pathPart)
+			temp, found, _ := l.findSubCommand(lastFoundCommand, pathPart)
 			if !found {
 				//now that we've at least found something, we check if a partial find was requested, if so return last found results
 				if partialAllowed {
@@ -214,8 +220,44 @@ func (l *Lamp) searchPathForCommand(path []string, partialAllowed bool) (*Comman
 	return lastFoundCommand, lastFoundResult, lastFoundAt
 }
 
+// findSubCommand finds the named subcommand of command, if PrefixMatching is enabled and there is no exact match the
+// name may also be a prefix of a single subcommand's name or alias. An error is returned if the prefix is ambiguous.
+func (l *Lamp) findSubCommand(command *Command, name string) (*Command, bool, error) {
+	if sc, found := command.findSubCommand(name); found || !l.PrefixMatching || name == "" {
+		return sc, found, nil
+	}
+
+	var matched *Command
+	var candidates []string
+	for _, sc := range command.SubCommands {
+		if sc.Secret {
+			continue
+		}
+
+		for _, candidate := range append([]string{sc.Name}, sc.Aliases...) {
+			if strings.HasPrefix(candidate, name) {
+				if matched != sc {
+					candidates = append(candidates, sc.Name)
+				}
+				matched = sc
+				break
+			}
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		return nil, false, nil
+	case 1:
+		return matched, true, nil
+	}
+
+	sort.Strings(candidates)
+	return nil, false, &AmbiguousCommandError{Path: command.pathOrName(), Name: name, Candidates: candidates}
+}
+
 func askedForVersion(args []string) bool {
-	for _, flag := range args {
+	for _, flag := range beforeTerminator(args) {
 		if flag == "-version" || flag == "--version" {
 			return true
 		}
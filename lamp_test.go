@@ -2,23 +2,17 @@ package genie
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"flag"
 	"io/ioutil"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
-func TestError_Error(t *testing.T) {
-	t.Run("validate error string", func(t *testing.T) {
-		want := "heyo error"
-		got := Error("heyo error")
-		if want != got.Error() {
-			t.Errorf("want %s, got %s", want, got)
-		}
-	})
-}
-
 func Test_NewLamp(t *testing.T) {
 	t.Run("validate new", func(t *testing.T) {
 		wantName := "tester"
@@ -40,6 +34,12 @@ func Test_NewLamp(t *testing.T) {
 		if got.Err != os.Stderr {
 			t.Errorf("want %v, got %v", os.Stderr, got.Err)
 		}
+		if got.Exit == nil {
+			t.Error("want os.Exit, got nil")
+		}
+		if got.MaxCommandDepth != 0 {
+			t.Errorf("want 0, got %d", got.MaxCommandDepth)
+		}
 
 		if got.RootCommand == nil {
 			t.Fatal("want root command, got nil")
@@ -233,6 +233,40 @@ func TestLamp_GrantWith(t *testing.T) {
 	})
 }
 
+func TestLamp_GrantContext(t *testing.T) {
+	t.Run("validate context is provided to command", func(t *testing.T) {
+		type key struct{}
+		b := bytes.NewBufferString("")
+		want := "granted"
+		subject := &Lamp{
+			Name: "test",
+			RootCommand: &Command{
+				Name: "test",
+				Out:  b,
+				Err:  b,
+				Run: func(command *Command) error {
+					command.Out.Write([]byte(command.Context().Value(key{}).(string)))
+					return nil
+				},
+			},
+			Out:             b,
+			Err:             b,
+			MaxCommandDepth: 3,
+		}
+		_, err := subject.GrantContext(context.WithValue(context.Background(), key{}, "granted"), []string{"test"})
+		if err != nil {
+			t.Errorf("[err] want nil, got %s", err)
+		}
+		got, err := ioutil.ReadAll(b)
+		if err != nil {
+			t.Errorf("[err] want nil, got %s", err)
+		}
+		if string(got) != want {
+			t.Errorf("want: %s, got %s", want, string(got))
+		}
+	})
+}
+
 func TestLamp_Execute(t *testing.T) {
 	t.Run("validate that os.Args are provided", func(t *testing.T) {
 		oldArgs := os.Args
@@ -833,6 +867,285 @@ func TestLamp_ExecuteWith(t *testing.T) {
 	})
 }
 
+func TestLamp_ExecuteWith_terminator(t *testing.T) {
+	t.Run("validate args after terminator are passed to command", func(t *testing.T) {
+		b := bytes.NewBufferString("")
+		want := "grep --help -v"
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.SubCommands = []*Command{
+			{
+				Name: "exec",
+				Run: func(command *Command) error {
+					command.Out.Write([]byte(strings.Join(command.RawArgs(), " ")))
+					return nil
+				},
+			},
+		}
+		subject.SetWriters(b, b)
+
+		_, err := subject.ExecuteWith([]string{"magic", "exec", "--", "grep", "--help", "-v"})
+		if err != nil {
+			t.Errorf("[err] want nil, got %s", err)
+		}
+		got, err := ioutil.ReadAll(b)
+		if err != nil {
+			t.Errorf("[err] want nil, got %s", err)
+		}
+		if string(got) != want {
+			t.Errorf("want: %s, got %s", want, string(got))
+		}
+	})
+
+	t.Run("validate version after terminator is passed to root command", func(t *testing.T) {
+		b := bytes.NewBufferString("")
+		want := "-version"
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.Run = func(command *Command) error {
+			command.Out.Write([]byte(strings.Join(command.Flags.Args(), " ")))
+			return nil
+		}
+		subject.SetWriters(b, b)
+
+		_, err := subject.ExecuteWith([]string{"magic", "--", "-version"})
+		if err != nil {
+			t.Errorf("[err] want nil, got %s", err)
+		}
+		got, err := ioutil.ReadAll(b)
+		if err != nil {
+			t.Errorf("[err] want nil, got %s", err)
+		}
+		if string(got) != want {
+			t.Errorf("want: %s, got %s", want, string(got))
+		}
+	})
+}
+
+func TestLamp_ExecuteWith_depth(t *testing.T) {
+	t.Run("validate deeply nested command runs without depth limit", func(t *testing.T) {
+		b := bytes.NewBufferString("")
+		want := "test one two three four five ran with flag value arg"
+		subject := NewLamp("test", "0.0.0", true)
+		parent := subject.RootCommand
+		for _, name := range []string{"one", "two", "three", "four", "five"} {
+			c := NewCommand(name, true)
+			c.Flags.String("flag", "", "a flag")
+			c.Run = func(command *Command) error {
+				command.Out.Write([]byte(command.Path() + " ran with " + command.FlagsAndArgs()))
+				return nil
+			}
+			parent.SubCommands = []*Command{c}
+			parent = c
+		}
+		subject.SetWriters(b, b)
+
+		_, err := subject.ExecuteWith([]string{"test", "one", "two", "three", "four", "five", "-flag", "value", "arg"})
+		if err != nil {
+			t.Errorf("[err] want nil, got %s", err)
+		}
+		got, err := ioutil.ReadAll(b)
+		if err != nil {
+			t.Errorf("[err] want nil, got %s", err)
+		}
+		if string(got) != want {
+			t.Errorf("want: %s, got %s", want, string(got))
+		}
+	})
+
+	t.Run("validate deeply nested command runs within depth limit", func(t *testing.T) {
+		b := bytes.NewBufferString("")
+		want := "test one two three four ran with arg"
+		subject := NewLamp("test", "0.0.0", true)
+		parent := subject.RootCommand
+		for _, name := range []string{"one", "two", "three", "four", "five"} {
+			c := NewCommand(name, true)
+			c.Flags.String("flag", "", "a flag")
+			c.Run = func(command *Command) error {
+				command.Out.Write([]byte(command.Path() + " ran with " + command.FlagsAndArgs()))
+				return nil
+			}
+			parent.SubCommands = []*Command{c}
+			parent = c
+		}
+		subject.SetWriters(b, b)
+		subject.MaxCommandDepth = 5
+
+		_, err := subject.ExecuteWith([]string{"test", "one", "two", "three", "four", "arg"})
+		if err != nil {
+			t.Errorf("[err] want nil, got %s", err)
+		}
+		got, err := ioutil.ReadAll(b)
+		if err != nil {
+			t.Errorf("[err] want nil, got %s", err)
+		}
+		if string(got) != want {
+			t.Errorf("want: %s, got %s", want, string(got))
+		}
+	})
+
+	t.Run("validate deeply nested command errors outside depth limit", func(t *testing.T) {
+		want := ErrCommandDepthInvalid
+		subject := NewLamp("test", "0.0.0", true)
+		parent := subject.RootCommand
+		for _, name := range []string{"one", "two", "three", "four", "five"} {
+			c := NewCommand(name, true)
+			c.Flags.String("flag", "", "a flag")
+			c.Run = func(command *Command) error {
+				command.Out.Write([]byte(command.Path() + " ran with " + command.FlagsAndArgs()))
+				return nil
+			}
+			parent.SubCommands = []*Command{c}
+			parent = c
+		}
+		subject.SetWriters(bytes.NewBufferString(""), bytes.NewBufferString(""))
+		subject.MaxCommandDepth = 5
+
+		_, got := subject.ExecuteWith([]string{"test", "one", "two", "three", "four", "five"})
+		if !errors.Is(got, want) {
+			t.Errorf("want %s, got %s", want, got)
+		}
+	})
+
+	t.Run("validate depth error exposes the command path and name", func(t *testing.T) {
+		subject := NewLamp("test", "0.0.0", true)
+		parent := subject.RootCommand
+		for _, name := range []string{"one", "two", "three", "four", "five"} {
+			c := NewCommand(name, true)
+			c.Flags.String("flag", "", "a flag")
+			c.Run = func(command *Command) error {
+				command.Out.Write([]byte(command.Path() + " ran with " + command.FlagsAndArgs()))
+				return nil
+			}
+			parent.SubCommands = []*Command{c}
+			parent = c
+		}
+		subject.SetWriters(bytes.NewBufferString(""), bytes.NewBufferString(""))
+		subject.MaxCommandDepth = 2
+
+		_, err := subject.ExecuteWith([]string{"test", "one", "two"})
+		var got *UsageError
+		if !errors.As(err, &got) {
+			t.Fatalf("want usage error, got %v", err)
+		}
+
+		if got.Path != "test one two" || got.Token != "two" {
+			t.Errorf("want test one two and two, got %s and %s", got.Path, got.Token)
+		}
+	})
+}
+
+func TestLamp_validateDepth(t *testing.T) {
+	t.Run("validate no limit", func(t *testing.T) {
+		subject := &Lamp{}
+		got := subject.validateDepth(&Command{depth: 100})
+		if got != nil {
+			t.Errorf("want nil, got %s", got)
+		}
+	})
+
+	t.Run("validate limit", func(t *testing.T) {
+		subject := &Lamp{MaxCommandDepth: 2}
+		got := subject.validateDepth(&Command{depth: 1})
+		if got != nil {
+			t.Errorf("want nil, got %s", got)
+		}
+		got = subject.validateDepth(&Command{depth: 2})
+		if !errors.Is(got, ErrCommandDepthInvalid) {
+			t.Errorf("want %s, got %s", ErrCommandDepthInvalid, got)
+		}
+	})
+}
+
+func TestLamp_ExecuteContext(t *testing.T) {
+	t.Run("validate context is provided to subcommand", func(t *testing.T) {
+		type key struct{}
+		b := bytes.NewBufferString("")
+		want := "subcommand ran with context"
+		subject := &Lamp{
+			Name: "test",
+			RootCommand: &Command{
+				Name: "test",
+				SubCommands: []*Command{
+					{
+						Name: "command",
+						Out:  b,
+						Err:  b,
+						Check: func(command *Command) error {
+							if command.Context().Value(key{}) == nil {
+								t.Error("want context value in check, got nil")
+							}
+							return nil
+						},
+						Run: func(command *Command) error {
+							command.Out.Write([]byte(command.Context().Value(key{}).(string)))
+							return nil
+						},
+					},
+				},
+			},
+			Out:             b,
+			Err:             b,
+			MaxCommandDepth: 3,
+		}
+		ctx := context.WithValue(context.Background(), key{}, "subcommand ran with context")
+		gotCommand, err := subject.ExecuteContext(ctx, []string{"test", "command", "-flag", "value"})
+		if err != nil {
+			t.Errorf("[err] want nil, got %s", err)
+		}
+		if gotCommand.Context().Value(key{}) != want {
+			t.Errorf("want %s, got %v", want, gotCommand.Context().Value(key{}))
+		}
+		got, err := ioutil.ReadAll(b)
+		if err != nil {
+			t.Errorf("[err] want nil, got %s", err)
+		}
+		if string(got) != want {
+			t.Errorf("want: %s, got %s", want, string(got))
+		}
+	})
+
+	t.Run("validate cancelled context stops command before run", func(t *testing.T) {
+		want := context.Canceled
+		subject := &Lamp{
+			Name: "test",
+			RootCommand: &Command{
+				Name: "test",
+				Run: func(command *Command) error {
+					t.Error("unexpected command run")
+					return nil
+				},
+			},
+			MaxCommandDepth: 3,
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, got := subject.ExecuteContext(ctx, []string{"test", "arg"})
+		if !errors.Is(got, want) {
+			t.Errorf("want %s, got %s", want, got)
+		}
+	})
+
+	t.Run("validate cancelling context from run is visible to command", func(t *testing.T) {
+		want := context.DeadlineExceeded
+		subject := &Lamp{
+			Name: "test",
+			RootCommand: &Command{
+				Name: "test",
+				Run: func(command *Command) error {
+					<-command.Context().Done()
+					return command.Context().Err()
+				},
+			},
+			MaxCommandDepth: 3,
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, got := subject.ExecuteContext(ctx, []string{"test"})
+		if !errors.Is(got, want) {
+			t.Errorf("want %s, got %s", want, got)
+		}
+	})
+}
+
 func TestLamp_ExecuteWith_error(t *testing.T) {
 	t.Run("validate lamp returns error if no args provided", func(t *testing.T) {
 		want := ErrNoArgs
@@ -844,7 +1157,7 @@ func TestLamp_ExecuteWith_error(t *testing.T) {
 			MaxCommandDepth: 3,
 		}
 		_, got := subject.ExecuteWith(nil)
-		if got != want {
+		if !errors.Is(got, want) {
 			t.Errorf("want %s, got %s", want, got)
 		}
 	})
@@ -856,7 +1169,7 @@ func TestLamp_ExecuteWith_error(t *testing.T) {
 			MaxCommandDepth: 3,
 		}
 		_, got := subject.ExecuteWith([]string{"test"})
-		if got != want {
+		if !errors.Is(got, want) {
 			t.Errorf("want %s, got %s", want, got)
 		}
 	})
@@ -868,13 +1181,13 @@ func TestLamp_ExecuteWith_error(t *testing.T) {
 			MaxCommandDepth: 3,
 		}
 		_, got := subject.ExecuteWith([]string{"test", "nope"})
-		if got != want {
+		if !errors.Is(got, want) {
 			t.Errorf("want %s, got %s", want, got)
 		}
 	})
 
 	t.Run("validate lamp returns error if invalid command called and root not runnable - no flag", func(t *testing.T) {
-		want := ErrCommandNotRunnable
+		want := ErrCommandNotFound
 		subject := &Lamp{
 			Name: "test",
 			RootCommand: &Command{
@@ -892,7 +1205,7 @@ func TestLamp_ExecuteWith_error(t *testing.T) {
 			MaxCommandDepth: 3,
 		}
 		_, got := subject.ExecuteWith([]string{"test", "nope"})
-		if got != want {
+		if !errors.Is(got, want) {
 			t.Errorf("want %s, got %s", want, got)
 		}
 	})
@@ -912,7 +1225,7 @@ func TestLamp_ExecuteWith_error(t *testing.T) {
 			MaxCommandDepth: 3,
 		}
 		_, got := subject.ExecuteWith([]string{"test", "command", "nope"})
-		if got != want {
+		if !errors.Is(got, want) {
 			t.Errorf("want %s, got %s", want, got)
 		}
 	})
@@ -936,13 +1249,13 @@ func TestLamp_ExecuteWith_error(t *testing.T) {
 			MaxCommandDepth: 3,
 		}
 		_, got := subject.ExecuteWith([]string{"test", "nope", "-flag", "value"})
-		if got != want {
+		if !errors.Is(got, want) {
 			t.Errorf("want %s, got %s", want, got)
 		}
 	})
 
-	t.Run("validate lamp returns error if invalid subcommand called", func(t *testing.T) {
-		want := ErrCommandNotFound
+	t.Run("validate lamp runs command with arguments if invalid subcommand called", func(t *testing.T) {
+		ran := false
 		subject := &Lamp{
 			Name: "test",
 			RootCommand: &Command{
@@ -951,7 +1264,7 @@ func TestLamp_ExecuteWith_error(t *testing.T) {
 					{
 						Name: "command",
 						Run: func(command *Command) error {
-							t.Error("unexpected command run")
+							ran = true
 							return nil
 						},
 						SubCommands: []*Command{
@@ -968,8 +1281,41 @@ func TestLamp_ExecuteWith_error(t *testing.T) {
 			},
 			MaxCommandDepth: 3,
 		}
+		_, err := subject.ExecuteWith([]string{"test", "command", "nope", "-flag", "value"})
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		if !ran {
+			t.Errorf("want command run")
+		}
+	})
+
+	t.Run("validate lamp returns error if invalid subcommand called on command that can't run", func(t *testing.T) {
+		want := ErrCommandNotFound
+		subject := &Lamp{
+			Name: "test",
+			RootCommand: &Command{
+				Name: "test",
+				SubCommands: []*Command{
+					{
+						Name: "command",
+						SubCommands: []*Command{
+							{
+								Name: "subcommand",
+								Run: func(command *Command) error {
+									t.Error("unexpected subcommand run")
+									return nil
+								},
+							},
+						},
+					},
+				},
+			},
+			MaxCommandDepth: 3,
+		}
 		_, got := subject.ExecuteWith([]string{"test", "command", "nope", "-flag", "value"})
-		if got != want {
+		if !errors.Is(got, want) {
 			t.Errorf("want %s, got %s", want, got)
 		}
 	})
@@ -1002,7 +1348,7 @@ func TestLamp_ExecuteWith_error(t *testing.T) {
 			MaxCommandDepth: 3,
 		}
 		_, got := subject.ExecuteWith([]string{"test", "nope", "subcommand", "-flag", "value"})
-		if got != want {
+		if !errors.Is(got, want) {
 			t.Errorf("want %s, got %s", want, got)
 		}
 	})
@@ -1027,6 +1373,11 @@ func TestLamp_ExecuteWith_error(t *testing.T) {
 									t.Error("unexpected subcommand run")
 									return nil
 								},
+								SubCommands: []*Command{
+									{
+										Name: "subsubcommand",
+									},
+								},
 							},
 						},
 					},
@@ -1035,7 +1386,7 @@ func TestLamp_ExecuteWith_error(t *testing.T) {
 			MaxCommandDepth: 3,
 		}
 		_, got := subject.ExecuteWith([]string{"test", "command", "subcommand", "subsubcommand", "-flag", "value"})
-		if got != want {
+		if !errors.Is(got, want) {
 			t.Errorf("want %s, got %s", want, got)
 		}
 	})
@@ -1073,7 +1424,7 @@ func TestLamp_ExecuteWith_error(t *testing.T) {
 			MaxCommandDepth: 3,
 		}
 		_, got := subject.ExecuteWith([]string{"test", "command", "subcommand", "subsubcommand", "arg1", "arg2"})
-		if got != want {
+		if !errors.Is(got, want) {
 			t.Errorf("want %s, got %s", want, got)
 		}
 	})
@@ -1313,43 +1664,104 @@ func TestLamp_searchPathForCommand(t *testing.T) {
 	})
 }
 
-func TestLamp_hasFlags(t *testing.T) {
-	t.Run("validate flag is found in correct position", func(t *testing.T) {
-		want := 3
-		args := []string{"interface", "command", "subcommand", "-flag", "value", "heyo"}
-		subject := &Lamp{}
-		got, hasFlag := subject.hasFlags(args)
-		if !hasFlag {
-			t.Errorf("want true, got %t", hasFlag)
+func TestLamp_findSubCommand(t *testing.T) {
+	testCases := []struct {
+		name           string
+		prefixMatching bool
+		find           string
+		want           string
+		wantErr        error
+	}{
+		{name: "exact", find: "wish", want: "wish"},
+		{name: "alias", find: "desire", want: "wish"},
+		{name: "prefix disabled", find: "wi"},
+		{name: "prefix", prefixMatching: true, find: "wi", want: "wish"},
+		{name: "alias prefix", prefixMatching: true, find: "des", want: "wish"},
+		{name: "exact secret wins over prefix", prefixMatching: true, find: "gr", want: "gr"},
+		{name: "secret not matched by prefix", prefixMatching: true, find: "sec"},
+		{name: "ambiguous", prefixMatching: true, find: "w", wantErr: ErrCommandAmbiguous},
+		{name: "empty", prefixMatching: true, find: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			subject := &Lamp{Name: "magic", PrefixMatching: tc.prefixMatching}
+			command := &Command{
+				Name: "magic",
+				SubCommands: []*Command{
+					{Name: "wish", Aliases: []string{"desire"}},
+					{Name: "wash"},
+					{Name: "grant"},
+					{Name: "gr", Secret: true},
+					{Name: "secret", Secret: true},
+				},
+			}
+			got, found, err := subject.findSubCommand(command, tc.find)
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("want %v, got %v", tc.wantErr, err)
+			}
+			if found != (tc.want != "") {
+				t.Errorf("want %t, got %t", tc.want != "", found)
+			}
+			if found && got.Name != tc.want {
+				t.Errorf("want %s, got %s", tc.want, got.Name)
+			}
+		})
+	}
+}
+
+func TestLamp_ExecuteWith_prefixMatching(t *testing.T) {
+	t.Run("validate unambiguous prefixes find commands", func(t *testing.T) {
+		b := bytes.NewBufferString("")
+		want := "magic wish big ran"
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.PrefixMatching = true
+		run := func(command *Command) error {
+			command.Out.Write([]byte(command.Path() + " ran"))
+			return nil
 		}
-		if got != want {
-			t.Errorf("want %d, got %d", want, got)
+		subject.RootCommand.SubCommands = []*Command{
+			{Name: "wish", Run: run, SubCommands: []*Command{{Name: "big", Run: run}}},
+			{Name: "wash", Run: run},
 		}
-	})
+		subject.SetWriters(b, b)
 
-	t.Run("validate flag is found in correct position (--)", func(t *testing.T) {
-		want := 2
-		args := []string{"interface", "command", "--flag", "value", "heyo"}
-		subject := &Lamp{}
-		got, hasFlag := subject.hasFlags(args)
-		if !hasFlag {
-			t.Errorf("want true, got %t", hasFlag)
+		_, err := subject.ExecuteWith([]string{"magic", "wi", "b"})
+		if err != nil {
+			t.Errorf("[err] want nil, got %s", err)
 		}
-		if got != want {
-			t.Errorf("want %d, got %d", want, got)
+		got, err := ioutil.ReadAll(b)
+		if err != nil {
+			t.Errorf("[err] want nil, got %s", err)
+		}
+		if string(got) != want {
+			t.Errorf("want: %s, got %s", want, string(got))
 		}
 	})
 
-	t.Run("validate flag is not found", func(t *testing.T) {
-		want := -1
-		args := []string{"interface", "command", "subcommand", "notaflag", "value"}
-		subject := &Lamp{}
-		got, hasFlag := subject.hasFlags(args)
-		if hasFlag {
-			t.Errorf("want false, got %t", hasFlag)
+	t.Run("validate ambiguous prefix returns error", func(t *testing.T) {
+		want := `ambiguous command "w" for "magic", could be "wash", "wish"`
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.PrefixMatching = true
+		run := func(command *Command) error {
+			command.Out.Write([]byte(command.Path() + " ran"))
+			return nil
 		}
-		if got != want {
-			t.Errorf("want %d, got %d", want, got)
+		subject.RootCommand.SubCommands = []*Command{
+			{Name: "wish", Run: run, SubCommands: []*Command{{Name: "big", Run: run}}},
+			{Name: "wash", Run: run},
+		}
+		subject.SetWriters(bytes.NewBufferString(""), bytes.NewBufferString(""))
+
+		gotCommand, got := subject.ExecuteWith([]string{"magic", "w"})
+		if !errors.Is(got, ErrCommandAmbiguous) {
+			t.Fatalf("want %s, got %v", ErrCommandAmbiguous, got)
+		}
+		if got.Error() != want {
+			t.Errorf("want %s, got %s", want, got)
+		}
+		if gotCommand != subject.RootCommand {
+			t.Errorf("want %v, got %v", subject.RootCommand, gotCommand)
 		}
 	})
 }
@@ -1371,6 +1783,14 @@ func Test_askedForVersion(t *testing.T) {
 		}
 	})
 
+	t.Run("validate version is not found after terminator", func(t *testing.T) {
+		args := []string{"interface", "command", "--", "-version"}
+		hasFlag := askedForVersion(args)
+		if hasFlag {
+			t.Errorf("want false, got %t", hasFlag)
+		}
+	})
+
 	t.Run("validate version is not found", func(t *testing.T) {
 		args := []string{"interface", "command", "subcommand", "-flag", "value", "-d"}
 		hasFlag := askedForVersion(args)
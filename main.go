@@ -0,0 +1,136 @@
+package genie
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+)
+
+const (
+	ExitCodeOK    = 0
+	ExitCodeError = 1 //default exit code for errors
+	ExitCodeUsage = 2 //default exit code for usage errors, e.g. unknown commands or flags
+)
+
+// ExitCoder is implemented by errors that control the exit code used by Lamp.Main.
+type ExitCoder interface {
+	ExitCode() int
+}
+
+// UsagePolicy controls when Lamp.Main shows the executed command's usage after an error.
+type UsagePolicy int
+
+const (
+	UsageOnUsageError UsagePolicy = iota //show usage only for usage errors, this is the default
+	UsageOnError                         //show usage for any error
+	UsageNever                           //never show usage
+)
+
+// exitError wraps an error with an exit code.
+type exitError struct {
+	err  error
+	code int
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+
+func (e *exitError) Unwrap() error { return e.err }
+
+func (e *exitError) ExitCode() int { return e.code }
+
+// WithExitCode returns an error that will cause Lamp.Main to exit with code, returns nil if err is nil.
+func WithExitCode(err error, code int) error {
+	if err == nil {
+		return nil
+	}
+
+	return &exitError{err: err, code: code}
+}
+
+// usageErrors are the errors caused by the user providing invalid commands, flags, or arguments.
+var usageErrors = []error{
+	ErrNoArgs,
+	ErrCommandDepthInvalid,
+	ErrCommandNotFound,
+	ErrCommandNotRunnable,
+	ErrCommandAmbiguous,
+	ErrFlagUnknown,
+	ErrFlagInvalid,
+	ErrFlagMissingValue,
+	ErrFlagSyntax,
+	ErrArgMissing,
+	ErrArgInvalid,
+	ErrArgsTooMany,
+	ErrArgsCount,
+}
+
+// IsUsageError returns true if the error was caused by invalid commands, flags, or arguments being provided.
+func IsUsageError(err error) bool {
+	var usageErr *UsageError
+	if errors.As(err, &usageErr) {
+		return true
+	}
+
+	for _, usageErr := range usageErrors {
+		if errors.Is(err, usageErr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ExitCode returns the exit code for err. If the error implements ExitCoder its code is used, otherwise nil and
+// flag.ErrHelp are ExitCodeOK, usage errors are ExitCodeUsage, and anything else is ExitCodeError.
+func ExitCode(err error) int {
+	var coder ExitCoder
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return ExitCodeOK
+	case errors.As(err, &coder):
+		return coder.ExitCode()
+	case IsUsageError(err):
+		return ExitCodeUsage
+	}
+
+	return ExitCodeError
+}
+
+// Main executes the Lamp with os.Args and exits. Errors are written to Err, followed by the executed command's usage if
+// required by the UsagePolicy. The exit code is determined by ExitCode.
+func (l *Lamp) Main() {
+	command, err := l.Execute()
+	l.exit(l.handleError(command, err))
+}
+
+// handleError writes err and usage according to the UsagePolicy, and returns the exit code for err.
+func (l *Lamp) handleError(command *Command, err error) int {
+	if err == nil {
+		return ExitCodeOK
+	}
+
+	if errors.Is(err, flag.ErrHelp) {
+		//help was asked for, but the command had nowhere to write its usage
+		if command != nil && l.Out != nil {
+			_, _ = fmt.Fprint(l.Out, command.ShowUsage())
+		}
+		return ExitCode(err)
+	}
+
+	var panicErr *PanicError
+	var fanOutErr *FanOutError
+	if errors.As(err, &panicErr) && !errors.As(err, &fanOutErr) {
+		//the command has already told the user what happened, a fan out still reports every failed item
+		return ExitCode(err)
+	}
+
+	if l.Err != nil {
+		_, _ = fmt.Fprintln(l.Err, err)
+		showUsage := l.UsagePolicy == UsageOnError || (l.UsagePolicy == UsageOnUsageError && IsUsageError(err))
+		if command != nil && showUsage {
+			_, _ = fmt.Fprint(l.Err, command.ShowUsage())
+		}
+	}
+
+	return ExitCode(err)
+}
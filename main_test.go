@@ -0,0 +1,203 @@
+package genie
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+type codedError struct{}
+
+func (c codedError) Error() string { return "coded" }
+
+func (c codedError) ExitCode() int { return 42 }
+
+func TestWithExitCode(t *testing.T) {
+	t.Run("validate nil stays nil", func(t *testing.T) {
+		if got := WithExitCode(nil, 3); got != nil {
+			t.Errorf("want nil, got %s", got)
+		}
+	})
+
+	t.Run("validate code and wrapping", func(t *testing.T) {
+		want := errors.New("heyo")
+		got := WithExitCode(want, 3)
+		if ExitCode(got) != 3 {
+			t.Errorf("want 3, got %d", ExitCode(got))
+		}
+		if !errors.Is(got, want) {
+			t.Errorf("want %s, got %s", want, got)
+		}
+		if got.Error() != want.Error() {
+			t.Errorf("want %s, got %s", want, got)
+		}
+	})
+}
+
+func TestExitCode(t *testing.T) {
+	testCases := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "nil", err: nil, want: ExitCodeOK},
+		{name: "help", err: flag.ErrHelp, want: ExitCodeOK},
+		{name: "exit coder", err: codedError{}, want: 42},
+		{name: "wrapped exit coder", err: fmt.Errorf("wrapped: %w", codedError{}), want: 42},
+		{name: "not found", err: &CommandNotFoundError{Path: "magic", Name: "wsh"}, want: ExitCodeUsage},
+		{name: "flag error", err: &FlagError{Flag: "tset", Err: ErrFlagUnknown}, want: ExitCodeUsage},
+		{name: "args error", err: &ArgsCountError{Min: 1, Max: 1}, want: ExitCodeUsage},
+		{name: "sentinel", err: ErrCommandNotRunnable, want: ExitCodeUsage},
+		{name: "other", err: errors.New("oops"), want: ExitCodeError},
+		{name: "cancelled", err: context.Canceled, want: ExitCodeError},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ExitCode(tc.err)
+			if got != tc.want {
+				t.Errorf("want %d, got %d", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestLamp_Main(t *testing.T) {
+	testCases := []struct {
+		name     string
+		args     []string
+		policy   UsagePolicy
+		wantCode int
+		wantOut  string
+		wantErr  string
+	}{
+		{
+			name:     "success",
+			args:     []string{"magic", "ok"},
+			wantCode: ExitCodeOK,
+			wantOut:  "ok ran",
+		},
+		{
+			name:     "usage error shows usage",
+			args:     []string{"magic", "wsh"},
+			wantCode: ExitCodeUsage,
+			wantErr:  "unknown command \"wsh\" for \"magic\"; did you mean \"wish\"?\n",
+		},
+		{
+			name:     "usage error without usage",
+			args:     []string{"magic", "wsh"},
+			policy:   UsageNever,
+			wantCode: ExitCodeUsage,
+			wantErr:  "unknown command \"wsh\" for \"magic\"; did you mean \"wish\"?\n",
+		},
+		{
+			name:     "error does not show usage",
+			args:     []string{"magic", "wish"},
+			wantCode: ExitCodeError,
+			wantErr:  "wish failed\n",
+		},
+		{
+			name:     "error shows usage",
+			args:     []string{"magic", "wish"},
+			policy:   UsageOnError,
+			wantCode: ExitCodeError,
+			wantErr:  "wish failed\n",
+		},
+		{
+			name:     "exit coder",
+			args:     []string{"magic", "grant"},
+			wantCode: 5,
+			wantErr:  "grant failed\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			oldArgs := os.Args
+			defer func() { os.Args = oldArgs }()
+			os.Args = tc.args
+			out := bytes.NewBufferString("")
+			errOut := bytes.NewBufferString("")
+			gotCode := -1
+			subject := NewLamp("magic", "0.0.0", true)
+			subject.Exit = func(c int) {
+				gotCode = c
+			}
+			subject.RootCommand.SubCommands = []*Command{
+				{
+					Name: "wish",
+					Run: func(command *Command) error {
+						return errors.New("wish failed")
+					},
+				},
+				{
+					Name: "grant",
+					Run: func(command *Command) error {
+						return WithExitCode(errors.New("grant failed"), 5)
+					},
+				},
+				{
+					Name: "ok",
+					Run: func(command *Command) error {
+						command.Out.Write([]byte("ok ran"))
+						return nil
+					},
+				},
+			}
+			subject.SetWriters(out, errOut)
+			subject.UsagePolicy = tc.policy
+
+			subject.Main()
+			if gotCode != tc.wantCode {
+				t.Errorf("want %d, got %d", tc.wantCode, gotCode)
+			}
+			gotOut, _ := ioutil.ReadAll(out)
+			if string(gotOut) != tc.wantOut {
+				t.Errorf("want %s, got %s", tc.wantOut, string(gotOut))
+			}
+
+			wantErr := tc.wantErr
+			showUsage := tc.policy == UsageOnError || (tc.policy == UsageOnUsageError && tc.wantCode == ExitCodeUsage)
+			if showUsage {
+				command, _, _ := subject.resolve(tc.args[1:2])
+				wantErr += command.ShowUsage()
+			}
+			gotErr, _ := ioutil.ReadAll(errOut)
+			if string(gotErr) != wantErr {
+				t.Errorf("want %s, got %s", wantErr, string(gotErr))
+			}
+		})
+	}
+
+	t.Run("validate help error shows usage on out", func(t *testing.T) {
+		oldArgs := os.Args
+		defer func() { os.Args = oldArgs }()
+		os.Args = []string{"magic", "ok"}
+		out := bytes.NewBufferString("")
+		gotCode := -1
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.Exit = func(c int) {
+			gotCode = c
+		}
+		ok := NewCommand("ok", true)
+		ok.Run = func(command *Command) error {
+			return flag.ErrHelp
+		}
+		subject.RootCommand.SubCommands = []*Command{ok}
+		subject.SetWriters(out, out)
+
+		subject.Main()
+		if gotCode != ExitCodeOK {
+			t.Errorf("want %d, got %d", ExitCodeOK, gotCode)
+		}
+		gotOut, _ := ioutil.ReadAll(out)
+		if string(gotOut) != ok.ShowUsage() {
+			t.Errorf("want usage, got %s", string(gotOut))
+		}
+	})
+}
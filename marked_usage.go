@@ -20,7 +20,11 @@ var DefaultCommandUsageMarkedFunc = func(command *Command) string {
 	if command.path == "" {
 		command.path = command.Name
 	}
-	syntax := fmt.Sprintf("%s %s", command.path, strings.ReplaceAll(command.RunSyntax, "{{path}}", command.path))
+	runSyntax := command.RunSyntax
+	if runSyntax == "" && len(command.Args) > 0 {
+		runSyntax = command.argsSyntax()
+	}
+	syntax := fmt.Sprintf("%s %s", command.path, strings.ReplaceAll(runSyntax, "{{path}}", command.path))
 	builder.WriteString(fmt.Sprintf("%s\n", strings.Trim(syntax, " ")))
 
 	if len(command.Aliases) > 0 {
@@ -46,12 +50,21 @@ var DefaultCommandUsageMarkedFunc = func(command *Command) string {
 	} else {
 		builder.WriteString(flagsUsageMarked(command))
 	}
+	builder.WriteString(globalFlagsUsageMarked(command))
 
-	if command.ArgInfo != "" {
+	if len(command.Args) > 0 || command.ArgInfo != "" {
 		builder.WriteString("\n::HEADER::ARGUMENTS:::HEADER-END::\n")
+		builder.WriteString(command.argsUsage("::ARG::%s::ARG-END::"))
+	}
+	if command.ArgInfo != "" {
 		builder.WriteString(fmt.Sprintf("%s\n", command.ArgInfo))
 	}
 
+	if env := envUsage(command, "::ENV::%s::ENV-END::", "::FLAG::%s::FLAG-END::"); env != "" {
+		builder.WriteString("\n::HEADER::ENVIRONMENT:::HEADER-END::\n")
+		builder.WriteString(env)
+	}
+
 	tabWriter := tabwriter.NewWriter(&builder, 0, 0, 4, ' ', tabwriter.DiscardEmptyColumns)
 	wroteCommandHeader := false
 	if len(command.SubCommands) > 0 {
@@ -81,9 +94,9 @@ var DefaultCommandUsageMarkedFunc = func(command *Command) string {
 var DefaultFlagsUsageMarkedFunc = func(command *Command) string {
 	//we'll remove the leading newline because in this context it's not needed
 	if command.MergeFlagUsage {
-		return strings.TrimPrefix(mergeFlagsUsageMarked(command), "\n")
+		return strings.TrimPrefix(mergeFlagsUsageMarked(command)+globalFlagsUsageMarked(command), "\n")
 	}
-	return strings.TrimPrefix(flagsUsageMarked(command), "\n")
+	return strings.TrimPrefix(flagsUsageMarked(command)+globalFlagsUsageMarked(command), "\n")
 }
 
 func mergeFlagsUsageMarked(command *Command) string {
@@ -93,7 +106,7 @@ func mergeFlagsUsageMarked(command *Command) string {
 	tabWriter := tabwriter.NewWriter(&builder, 0, 0, 4, ' ', tabwriter.DiscardEmptyColumns)
 	if command.Flags != nil {
 		command.Flags.VisitAll(func(f *flag.Flag) {
-			if command.flagIsSecret(f.Name) {
+			if command.flagIsSecret(f.Name) || command.globalFlags[f.Name] || command.isShorthand(f.Name) {
 				return
 			}
 			defaultVal := ""
@@ -101,34 +114,10 @@ func mergeFlagsUsageMarked(command *Command) string {
 				defaultVal = fmt.Sprintf(" (default %s)", f.DefValue)
 			}
 
-			typeOf := ""
-			switch fmt.Sprintf("%T", f.Value) {
-			case "*flag.boolValue":
-				typeOf = ""
-			case "*flag.durationValue":
-				typeOf = "duration"
-			case "*flag.float64Value":
-				typeOf = "float"
-			case "*flag.intValue", "*flag.int64Value":
-				typeOf = "int"
-			case "*flag.stringValue":
-				typeOf = "string"
-			case "*flag.uintValue", "*flag.uint64Value":
-				typeOf = "uint"
-			default:
-				u, ok := f.Value.(UsageAwareFlagValue)
-				if ok {
-					typeOf = u.Type()
-				}
-			}
-
-			dashes := "--"
-			if len(f.Name) == 1 {
-				dashes = "-"
-			}
+			typeOf := flagType(f)
 
-			usage := fmt.Sprintf("%s%s", f.Usage, defaultVal)
-			dashedFlag := fmt.Sprintf("%s%s", dashes, f.Name)
+			usage := fmt.Sprintf("%s%s%s", f.Usage, defaultVal, command.requiredMarker(f.Name))
+			dashedFlag := command.flagUsageName(f.Name)
 
 			existingFlags, exists := usages[usage]
 			if exists {
@@ -177,7 +166,7 @@ func flagsUsageMarked(command *Command) string {
 	builder.WriteString("\n::HEADER::FLAGS:::HEADER-END::\n") //all commands have at least --help
 	if command.Flags != nil {
 		command.Flags.VisitAll(func(f *flag.Flag) {
-			if command.flagIsSecret(f.Name) {
+			if command.flagIsSecret(f.Name) || command.globalFlags[f.Name] || command.isShorthand(f.Name) {
 				return
 			}
 
@@ -187,32 +176,12 @@ func flagsUsageMarked(command *Command) string {
 				defaultVal = fmt.Sprintf(" (default %s)", f.DefValue)
 			}
 
-			typeOf := ""
-			switch fmt.Sprintf("%T", f.Value) {
-			case "*flag.boolValue":
-				typeOf = ""
-			case "*flag.durationValue":
-				typeOf = " duration"
-			case "*flag.float64Value":
-				typeOf = " float"
-			case "*flag.intValue", "*flag.int64Value":
-				typeOf = " int"
-			case "*flag.stringValue":
-				typeOf = " string"
-			case "*flag.uintValue", "*flag.uint64Value":
-				typeOf = " uint"
-			default:
-				u, ok := f.Value.(UsageAwareFlagValue)
-				if ok {
-					typeOf = fmt.Sprintf(" %s", u.Type())
-				}
+			typeOf := flagType(f)
+			if typeOf != "" {
+				typeOf = " " + typeOf
 			}
 
-			dashes := "--"
-			if len(f.Name) == 1 {
-				dashes = "-"
-			}
-			usages = append(usages, fmt.Sprintf("::FLAG::%s%s::FLAG-END::\t%s\t%s%s\n", dashes, f.Name, typeOf, f.Usage, defaultVal))
+			usages = append(usages, fmt.Sprintf("::FLAG::%s::FLAG-END::\t%s\t%s%s%s\n", command.flagUsageName(f.Name), typeOf, f.Usage, defaultVal, command.requiredMarker(f.Name)))
 		})
 	}
 
@@ -236,3 +205,46 @@ func flagsUsageMarked(command *Command) string {
 	_ = tabWriter.Flush()
 	return builder.String()
 }
+
+func globalFlagsUsageMarked(command *Command) string {
+	flags := command.inheritedFlags()
+	if len(flags) == 0 {
+		return ""
+	}
+
+	var builder strings.Builder
+	var usages []string
+	merged := make(map[string]int)
+	tabWriter := tabwriter.NewWriter(&builder, 0, 0, 4, ' ', tabwriter.DiscardEmptyColumns)
+	builder.WriteString("\n::HEADER::GLOBAL FLAGS:::HEADER-END::\n")
+	for _, f := range flags {
+		defaultVal := ""
+		if f.DefValue != "" {
+			defaultVal = fmt.Sprintf(" (default %s)", f.DefValue)
+		}
+
+		typeOf := flagType(f)
+		if typeOf != "" {
+			typeOf = " " + typeOf
+		}
+
+		usage := fmt.Sprintf("%s%s%s", f.Usage, defaultVal, command.requiredMarker(f.Name))
+		if i, exists := merged[usage]; exists && command.MergeFlagUsage {
+			usages[i] = fmt.Sprintf("::FLAG::%s %s", command.flagUsageName(f.Name), strings.TrimPrefix(usages[i], "::FLAG::"))
+			continue
+		}
+		merged[usage] = len(usages)
+		usages = append(usages, fmt.Sprintf("::FLAG::%s::FLAG-END::\t%s\t%s\n", command.flagUsageName(f.Name), typeOf, usage))
+	}
+
+	sort.Slice(usages, func(i, j int) bool {
+		return usages[i] < usages[j]
+	})
+
+	for _, s := range usages {
+		_, _ = tabWriter.Write([]byte(s))
+	}
+
+	_ = tabWriter.Flush()
+	return builder.String()
+}
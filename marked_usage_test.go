@@ -355,6 +355,40 @@ command
 			t.Errorf("want: %s, got %s", want, string(got))
 		}
 	})
+
+	t.Run("validate default marked usage - global flags", func(t *testing.T) {
+		want := `::DESCRIPTION::A simple wish.::DESCRIPTION-END::
+
+::HEADER::USAGE:::HEADER-END::
+lamp wish
+
+::HEADER::FLAGS:::HEADER-END::
+::FLAG::--help::FLAG-END::               display help for command
+::FLAG::--test::FLAG-END::     string    the test flag
+
+::HEADER::GLOBAL FLAGS:::HEADER-END::
+::FLAG::--config::FLAG-END::         string    the config file
+::FLAG::--verbose -v::FLAG-END::               verbose output (default false)
+`
+		root := NewCommand("lamp", false)
+		root.PersistentFlags.String("config", "", "the config file")
+		root.PersistentFlags.Bool("verbose", false, "verbose output")
+		root.PersistentFlags.Bool("v", false, "verbose output")
+		root.PersistentFlags.String("hideme", "", "i should not show up")
+		root.SecretFlag("hideme")
+		subject := NewCommand("wish", false)
+		subject.Description = "A simple wish."
+		subject.MergeFlagUsage = true
+		subject.Usage = DefaultCommandUsageMarkedFunc
+		subject.Flags.String("test", "", "the test flag")
+		root.SubCommands = []*Command{subject}
+		root.AnchorPaths()
+
+		got := subject.ShowUsage()
+		if got != want {
+			t.Errorf("want: %s got: %s", want, got)
+		}
+	})
 }
 
 func Test_DefaultFlagsUsageMarkedFunc(t *testing.T) {
@@ -0,0 +1,43 @@
+package genie
+
+// Middleware wraps a RunFunc, allowing behaviour such as logging, timing, or auditing to be added around the
+// execution of every command without changing each RunFunc. Call next to continue execution.
+type Middleware func(next RunFunc) RunFunc
+
+// Use adds middleware to the Lamp, which wraps the execution of every command the Lamp executes.
+func (l *Lamp) Use(middleware ...Middleware) {
+	l.Middleware = append(l.Middleware, middleware...)
+}
+
+// Use adds middleware to the command, which wraps the execution of the command and all of its subcommands.
+func (c *Command) Use(middleware ...Middleware) {
+	c.Middleware = append(c.Middleware, middleware...)
+}
+
+// middlewareChain returns the middleware that applies to the command in the order it should wrap execution, outermost
+// first. That is the Lamp's middleware, followed by the middleware of the command and each of its parents from the
+// root down, each in the order it was added.
+func (c *Command) middlewareChain() []Middleware {
+	var chain []Middleware
+	for cmd := c; cmd != nil; cmd = cmd.parent {
+		chain = append(append([]Middleware{}, cmd.Middleware...), chain...)
+	}
+
+	if c.lamp != nil {
+		chain = append(append([]Middleware{}, c.lamp.Middleware...), chain...)
+	}
+
+	return chain
+}
+
+// wrap returns run wrapped by the command's middleware chain.
+func (c *Command) wrap(run RunFunc) RunFunc {
+	chain := c.middlewareChain()
+	for i := len(chain) - 1; i >= 0; i-- {
+		if chain[i] != nil {
+			run = chain[i](run)
+		}
+	}
+
+	return run
+}
@@ -0,0 +1,148 @@
+package genie
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCommand_middleware(t *testing.T) {
+	record := func(calls *[]string, name string) Middleware {
+		return func(next RunFunc) RunFunc {
+			return func(command *Command) error {
+				*calls = append(*calls, name+">")
+				err := next(command)
+				*calls = append(*calls, "<"+name)
+				return err
+			}
+		}
+	}
+
+	t.Run("validate middleware is composed from lamp to leaf", func(t *testing.T) {
+		want := "lamp1> lamp2> root> wish> sub> pre run <sub <wish <root <lamp2 <lamp1"
+		var calls []string
+		subject := NewLamp("magic", "0.0.0", true)
+		wish := NewCommand("wish", true)
+		sub := NewCommand("sub", true)
+		sub.PreRun = func(command *Command) error {
+			calls = append(calls, "pre")
+			return nil
+		}
+		sub.Run = func(command *Command) error {
+			calls = append(calls, "run")
+			return nil
+		}
+		wish.SubCommands = []*Command{sub}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.Use(record(&calls, "lamp1"), record(&calls, "lamp2"))
+		subject.RootCommand.Use(record(&calls, "root"))
+		wish.Use(record(&calls, "wish"))
+		sub.Use(record(&calls, "sub"))
+
+		_, err := subject.ExecuteWith([]string{"magic", "wish", "sub"})
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		got := strings.Join(calls, " ")
+		if got != want {
+			t.Errorf("want %s, got %s", want, got)
+		}
+	})
+
+	t.Run("validate middleware is inherited only from parents", func(t *testing.T) {
+		want := "lamp1> lamp2> root> wish> <wish <root <lamp2 <lamp1"
+		var calls []string
+		subject := NewLamp("magic", "0.0.0", true)
+		wish := NewCommand("wish", true)
+		sub := NewCommand("sub", true)
+		sub.PreRun = func(command *Command) error {
+			calls = append(calls, "pre")
+			return nil
+		}
+		sub.Run = func(command *Command) error {
+			calls = append(calls, "run")
+			return nil
+		}
+		wish.SubCommands = []*Command{sub}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.Use(record(&calls, "lamp1"), record(&calls, "lamp2"))
+		subject.RootCommand.Use(record(&calls, "root"))
+		wish.Use(record(&calls, "wish"))
+		sub.Use(record(&calls, "sub"))
+		wish.Run = func(command *Command) error {
+			return nil
+		}
+
+		_, err := subject.ExecuteWith([]string{"magic", "wish"})
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		got := strings.Join(calls, " ")
+		if got != want {
+			t.Errorf("want %s, got %s", want, got)
+		}
+	})
+
+	t.Run("validate middleware can short circuit and see errors", func(t *testing.T) {
+		want := errors.New("denied")
+		var calls []string
+		subject := NewLamp("magic", "0.0.0", true)
+		wish := NewCommand("wish", true)
+		sub := NewCommand("sub", true)
+		sub.PreRun = func(command *Command) error {
+			calls = append(calls, "pre")
+			return nil
+		}
+		sub.Run = func(command *Command) error {
+			calls = append(calls, "run")
+			return nil
+		}
+		wish.SubCommands = []*Command{sub}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.Use(record(&calls, "lamp1"), record(&calls, "lamp2"))
+		subject.RootCommand.Use(record(&calls, "root"))
+		wish.Use(record(&calls, "wish"))
+		sub.Use(record(&calls, "sub"))
+		subject.RootCommand.Use(func(next RunFunc) RunFunc {
+			return func(command *Command) error {
+				return want
+			}
+		})
+
+		_, got := subject.ExecuteWith([]string{"magic", "wish", "sub"})
+		if got != want {
+			t.Errorf("want %s, got %s", want, got)
+		}
+
+		if strings.Contains(strings.Join(calls, " "), "run") {
+			t.Errorf("want run skipped, got %v", calls)
+		}
+	})
+
+	t.Run("validate command middleware runs without a lamp", func(t *testing.T) {
+		want := "sub> pre run <sub"
+		var calls []string
+		sub := NewCommand("sub", true)
+		sub.PreRun = func(command *Command) error {
+			calls = append(calls, "pre")
+			return nil
+		}
+		sub.Run = func(command *Command) error {
+			calls = append(calls, "run")
+			return nil
+		}
+		sub.Use(record(&calls, "sub"))
+
+		err := sub.run([]string{})
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		got := strings.Join(calls, " ")
+		if got != want {
+			t.Errorf("want %s, got %s", want, got)
+		}
+	})
+}
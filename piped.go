@@ -0,0 +1,56 @@
+package genie
+
+import (
+	"io"
+	"os"
+)
+
+// PipedDetector reports whether in is receiving piped input, it's used to decide if PipedIn should be called.
+type PipedDetector func(in io.Reader) bool
+
+// DefaultPipedDetector reports input as piped when in can be stat'ed, like an *os.File, and is not a character device
+// (a terminal). Readers that can't be stat'ed are never considered piped.
+var DefaultPipedDetector PipedDetector = func(in io.Reader) bool {
+	file, ok := in.(interface{ Stat() (os.FileInfo, error) })
+	if !ok {
+		return false
+	}
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return false
+	}
+
+	return (fileInfo.Mode() & os.ModeCharDevice) == 0
+}
+
+// AlwaysPiped is a PipedDetector that always reports input as piped, useful for testing PipedIn with a Command.In
+// that is not a file.
+func AlwaysPiped(io.Reader) bool {
+	return true
+}
+
+// NeverPiped is a PipedDetector that never reports input as piped.
+func NeverPiped(io.Reader) bool {
+	return false
+}
+
+// stdin returns the command's input, os.Stdin if In is not set.
+func (c *Command) stdin() io.Reader {
+	if c.In == nil {
+		return os.Stdin
+	}
+
+	return c.In
+}
+
+// IsPiped returns true if the command's input is piped, using the command's Piped detector if set. The piped-input
+// helpers can be used regardless, but this allows a command to fall back to arguments when nothing was piped.
+func (c *Command) IsPiped() bool {
+	detector := c.Piped
+	if detector == nil {
+		detector = DefaultPipedDetector
+	}
+
+	return detector(c.stdin())
+}
@@ -0,0 +1,154 @@
+package genie
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDefaultPipedDetector(t *testing.T) {
+	t.Run("validate non file reader is not piped", func(t *testing.T) {
+		if DefaultPipedDetector(strings.NewReader("heyo")) {
+			t.Errorf("want false, got true")
+		}
+	})
+
+	t.Run("validate regular file is piped", func(t *testing.T) {
+		tmp, err := ioutil.TempFile(t.TempDir(), "stdin")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer tmp.Close()
+
+		if !DefaultPipedDetector(tmp) {
+			t.Errorf("want true, got false")
+		}
+	})
+
+	t.Run("validate closed file is not piped", func(t *testing.T) {
+		tmp, err := ioutil.TempFile(t.TempDir(), "stdin")
+		if err != nil {
+			t.Fatal(err)
+		}
+		tmp.Close()
+
+		if DefaultPipedDetector(tmp) {
+			t.Errorf("want false, got true")
+		}
+	})
+}
+
+func TestCommand_SetIn(t *testing.T) {
+	in := strings.NewReader("heyo")
+	subject := NewCommand("magic", true)
+	subject.SubCommands = []*Command{NewCommand("wish", true)}
+
+	if subject.In != os.Stdin || subject.SubCommands[0].In != os.Stdin {
+		t.Errorf("want %v, got %v", os.Stdin, subject.In)
+	}
+
+	subject.SetIn(in)
+	if subject.In != in || subject.SubCommands[0].In != in {
+		t.Errorf("want %v, got %v", in, subject.SubCommands[0].In)
+	}
+}
+
+func TestLamp_SetReader(t *testing.T) {
+	in := strings.NewReader("heyo")
+	subject := NewLamp("magic", "0.0.0", true)
+	subject.RootCommand.SubCommands = []*Command{NewCommand("wish", true)}
+
+	subject.SetReader(in, AlwaysPiped)
+	if subject.In != in || subject.RootCommand.In != in || subject.RootCommand.SubCommands[0].In != in {
+		t.Errorf("want %v, got %v", in, subject.RootCommand.SubCommands[0].In)
+	}
+
+	if !subject.RootCommand.SubCommands[0].IsPiped() {
+		t.Errorf("want piped, got not piped")
+	}
+
+	subject.SetReader(nil, nil)
+	if subject.In != in {
+		t.Errorf("want %v, got %v", in, subject.In)
+	}
+
+	if subject.RootCommand.SubCommands[0].IsPiped() {
+		t.Errorf("want not piped, got piped")
+	}
+}
+
+func TestCommand_run_pipedIn(t *testing.T) {
+	t.Run("validate piped in is called with forced detector", func(t *testing.T) {
+		want := "piped heyo"
+		b := bytes.NewBufferString("")
+		subject := NewCommand("wish", true)
+		subject.SetIn(strings.NewReader("heyo"))
+		subject.PipedIn = func(command *Command) (error, bool) {
+			data, err := ioutil.ReadAll(command.In)
+			b.WriteString("piped " + string(data))
+			return err, false
+		}
+		subject.Run = func(command *Command) error {
+			return nil
+		}
+		subject.Piped = AlwaysPiped
+
+		err := subject.run([]string{})
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		if b.String() != want {
+			t.Errorf("want %s, got %s", want, b.String())
+		}
+	})
+
+	t.Run("validate piped in is not called for non file reader by default", func(t *testing.T) {
+		b := bytes.NewBufferString("")
+		subject := NewCommand("wish", true)
+		subject.SetIn(strings.NewReader("heyo"))
+		subject.PipedIn = func(command *Command) (error, bool) {
+			data, err := ioutil.ReadAll(command.In)
+			b.WriteString("piped " + string(data))
+			return err, false
+		}
+		subject.Run = func(command *Command) error {
+			return nil
+		}
+
+		err := subject.run([]string{})
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		if b.String() != "" {
+			t.Errorf("want empty, got %s", b.String())
+		}
+	})
+
+	t.Run("validate piped in is not called when never piped", func(t *testing.T) {
+		b := bytes.NewBufferString("")
+		subject := NewCommand("wish", true)
+		subject.SetIn(strings.NewReader("heyo"))
+		subject.PipedIn = func(command *Command) (error, bool) {
+			data, err := ioutil.ReadAll(command.In)
+			b.WriteString("piped " + string(data))
+			return err, false
+		}
+		subject.Run = func(command *Command) error {
+			return nil
+		}
+		subject.Piped = NeverPiped
+
+		err := subject.run([]string{})
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		if b.String() != "" {
+			t.Errorf("want empty, got %s", b.String())
+		}
+	})
+}
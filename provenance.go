@@ -0,0 +1,207 @@
+package genie
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Source describes where the value of a flag came from.
+type Source int
+
+const (
+	SourceDefault Source = iota //the flag's default value
+	SourceConfig                //a config file
+	SourceEnv                   //an environment variable
+	SourceArgs                  //the command line
+)
+
+func (s Source) String() string {
+	switch s {
+	case SourceConfig:
+		return "config"
+	case SourceEnv:
+		return "env"
+	case SourceArgs:
+		return "argv"
+	}
+
+	return "default"
+}
+
+// FlagOrigin describes where the value of a flag came from.
+type FlagOrigin struct {
+	Source Source
+	Name   string //the config file or environment variable the value came from, if any
+}
+
+func (o FlagOrigin) String() string {
+	if o.Name != "" {
+		return fmt.Sprintf("%s (%s)", o.Source, o.Name)
+	}
+
+	return o.Source.String()
+}
+
+// FlagValue is the effective value of a flag and where it came from.
+type FlagValue struct {
+	Flag   string `json:"flag"`
+	Value  string `json:"value"`
+	Source Source `json:"-"`
+	From   string `json:"from,omitempty"` //the config file or environment variable the value came from, if any
+}
+
+func (v FlagValue) MarshalJSON() ([]byte, error) {
+	type plain FlagValue
+	return json.Marshal(struct {
+		plain
+		Source string `json:"source"`
+	}{plain(v), v.Source.String()})
+}
+
+// recordArgsOrigins records the flags provided on the command line, it must be called after the flags are parsed.
+func (c *Command) recordArgsOrigins() {
+	c.flagOrigins = make(map[string]FlagOrigin)
+	if c.Flags == nil {
+		return
+	}
+
+	c.Flags.Visit(func(f *flag.Flag) {
+		c.flagOrigins[c.canonicalFlag(f.Name)] = FlagOrigin{Source: SourceArgs}
+	})
+}
+
+// FlagSource returns where the named flag's value came from, false is returned if the command has no such flag.
+// Sources are recorded as the command executes, before Check is called.
+func (c *Command) FlagSource(name string) (FlagOrigin, bool) {
+	if c.Flags == nil || c.Flags.Lookup(name) == nil {
+		return FlagOrigin{}, false
+	}
+
+	name = c.canonicalFlag(name)
+
+	if origin, ok := c.flagOrigins[name]; ok {
+		return origin, true
+	}
+
+	if c.flagOrigins == nil && c.FlagWasProvided(name) {
+		return FlagOrigin{Source: SourceArgs}, true
+	}
+
+	return FlagOrigin{Source: SourceDefault}, true
+}
+
+// EffectiveConfig returns the value and source of every non-secret flag of the command, sorted by flag name.
+func (c *Command) EffectiveConfig() []FlagValue {
+	var values []FlagValue
+	if c.Flags == nil {
+		return values
+	}
+
+	c.Flags.VisitAll(func(f *flag.Flag) {
+		if c.flagIsSecret(f.Name) || c.isShorthand(f.Name) {
+			return
+		}
+		if declaring := c.declaringCommand(f.Name); declaring != nil && declaring.flagIsSecret(f.Name) {
+			return
+		}
+		if _, ok := f.Value.(*printConfigValue); ok {
+			return
+		}
+
+		origin, _ := c.FlagSource(f.Name)
+		values = append(values, FlagValue{Flag: f.Name, Value: f.Value.String(), Source: origin.Source, From: origin.Name})
+	})
+
+	return values
+}
+
+// WriteEffectiveConfig writes the command's EffectiveConfig to w, format is either "text" or "json".
+func (c *Command) WriteEffectiveConfig(w io.Writer, format string) error {
+	values := c.EffectiveConfig()
+	switch format {
+	case "json":
+		if values == nil {
+			values = []FlagValue{}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(values)
+	case "text", "":
+		tabWriter := tabwriter.NewWriter(w, 0, 0, 4, ' ', 0)
+		for _, v := range values {
+			origin := FlagOrigin{Source: v.Source, Name: v.From}
+			_, _ = fmt.Fprintf(tabWriter, "%s\t%s\t%s\n", dashed(v.Flag), v.Value, origin)
+		}
+		return tabWriter.Flush()
+	}
+
+	return fmt.Errorf("%w: unknown config format %q", ErrFlagInvalid, format)
+}
+
+// printConfigValue is the value of the --print-config flag, it acts as a boolean flag that also accepts a format.
+type printConfigValue struct {
+	format string
+}
+
+func (p *printConfigValue) String() string {
+	if p == nil {
+		return ""
+	}
+
+	return p.format
+}
+
+func (p *printConfigValue) Set(s string) error {
+	switch strings.ToLower(s) {
+	case "true", "text":
+		p.format = "text"
+	case "false":
+		p.format = ""
+	case "json":
+		p.format = "json"
+	default:
+		return fmt.Errorf("unknown format %q, must be text or json", s)
+	}
+
+	return nil
+}
+
+func (p *printConfigValue) IsBoolFlag() bool {
+	return true
+}
+
+func (p *printConfigValue) Type() string {
+	return "[=format]"
+}
+
+// EnablePrintConfig adds a persistent --print-config flag to the root command. When provided, the executed command
+// writes the value and source of each of its non-secret flags to Out, instead of running. Use --print-config=json
+// for JSON output.
+func (l *Lamp) EnablePrintConfig() {
+	if l.RootCommand.PersistentFlags == nil {
+		l.RootCommand.PersistentFlags = l.RootCommand.newFlagSet()
+	}
+
+	if l.RootCommand.PersistentFlags.Lookup("print-config") == nil {
+		l.RootCommand.PersistentFlags.Var(&printConfigValue{}, "print-config", "print the effective value and source of each flag, and exit")
+	}
+}
+
+// printConfigFormat returns the format of the effective config the user asked to print, if they did.
+func (c *Command) printConfigFormat() string {
+	if c.Flags == nil {
+		return ""
+	}
+
+	if f := c.Flags.Lookup("print-config"); f != nil {
+		if v, ok := f.Value.(*printConfigValue); ok {
+			return v.format
+		}
+	}
+
+	return ""
+}
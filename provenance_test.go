@@ -0,0 +1,242 @@
+package genie
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSource_String(t *testing.T) {
+	for want, subject := range map[string]Source{"default": SourceDefault, "config": SourceConfig, "env": SourceEnv, "argv": SourceArgs} {
+		if subject.String() != want {
+			t.Errorf("want %s, got %s", want, subject)
+		}
+	}
+}
+
+func TestCommand_FlagSource(t *testing.T) {
+	t.Setenv("MAGIC_NAME", "jafar")
+	file := filepath.Join(t.TempDir(), "magic.conf")
+	if err := ioutil.WriteFile(file, []byte("[magic wish]\ncount = 3\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	subject := NewLamp("magic", "0.0.0", true)
+	subject.AutomaticEnv = true
+	subject.RootCommand.PersistentFlags.String("name", "genie", "your name")
+	subject.RootCommand.PersistentFlags.String("token", "", "api token")
+	subject.RootCommand.SecretFlag("token")
+	subject.SetConfigFile(file)
+	subject.EnablePrintConfig()
+	wish := NewCommand("wish", true)
+	wish.Flags.Int("count", 1, "how many wishes")
+	wish.Flags.Bool("loud", false, "wish loudly")
+	wish.Flags.String("who", "", "who is wishing")
+	wish.Args = []*Arg{{Name: "wish", Required: true}}
+	wish.Run = func(command *Command) error {
+		return nil
+	}
+	subject.RootCommand.SubCommands = []*Command{wish}
+	subject.SetWriters(bytes.NewBufferString(""), bytes.NewBufferString(""))
+
+	_, err := subject.ExecuteWith([]string{"magic", "wish", "--loud", "a"})
+	if err != nil {
+		t.Fatalf("want nil, got %s", err)
+	}
+
+	tests := map[string]string{
+		"loud":  "argv",
+		"name":  "env (MAGIC_NAME)",
+		"count": "config (" + subject.ConfigFile + ")",
+		"who":   "default",
+	}
+	for name, want := range tests {
+		got, ok := wish.FlagSource(name)
+		if !ok || got.String() != want {
+			t.Errorf("want %s for %s, got %s", want, name, got)
+		}
+	}
+
+	if _, ok := wish.FlagSource("nope"); ok {
+		t.Errorf("want no source for unknown flag")
+	}
+}
+
+func TestCommand_FlagsAndArgs_provenance(t *testing.T) {
+	t.Run("validate flags from env and config are not reported as provided", func(t *testing.T) {
+		want := "loud true a"
+		t.Setenv("MAGIC_NAME", "jafar")
+		file := filepath.Join(t.TempDir(), "magic.conf")
+		if err := ioutil.WriteFile(file, []byte("[magic wish]\ncount = 3\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.AutomaticEnv = true
+		subject.RootCommand.PersistentFlags.String("name", "genie", "your name")
+		subject.SetConfigFile(file)
+		wish := NewCommand("wish", true)
+		wish.Flags.Int("count", 1, "how many wishes")
+		wish.Flags.Bool("loud", false, "wish loudly")
+		wish.Run = func(command *Command) error {
+			return nil
+		}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.SetWriters(bytes.NewBufferString(""), bytes.NewBufferString(""))
+
+		_, err := subject.ExecuteWith([]string{"magic", "wish", "--loud", "a"})
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		if wish.FlagsAndArgs() != want {
+			t.Errorf("want %s, got %s", want, wish.FlagsAndArgs())
+		}
+	})
+}
+
+func TestCommand_FlagSource_withoutExecution(t *testing.T) {
+	subject := NewCommand("wish", true)
+	subject.Flags.Bool("loud", false, "wish loudly")
+	_ = subject.Flags.Parse([]string{"--loud"})
+
+	got, ok := subject.FlagSource("loud")
+	if !ok || got.Source != SourceArgs {
+		t.Errorf("want argv, got %s", got)
+	}
+}
+
+func TestLamp_EnablePrintConfig(t *testing.T) {
+	t.Run("validate text output", func(t *testing.T) {
+		t.Setenv("MAGIC_NAME", "jafar")
+		b := bytes.NewBufferString("")
+		file := filepath.Join(t.TempDir(), "magic.conf")
+		if err := ioutil.WriteFile(file, []byte("[magic wish]\ncount = 3\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.AutomaticEnv = true
+		subject.RootCommand.PersistentFlags.String("name", "genie", "your name")
+		subject.RootCommand.PersistentFlags.String("token", "", "api token")
+		subject.RootCommand.SecretFlag("token")
+		subject.SetConfigFile(file)
+		subject.EnablePrintConfig()
+		wish := NewCommand("wish", true)
+		wish.Flags.Int("count", 1, "how many wishes")
+		wish.Flags.Bool("loud", false, "wish loudly")
+		wish.Flags.String("who", "", "who is wishing")
+		wish.Args = []*Arg{{Name: "wish", Required: true}}
+		ran := false
+		wish.Run = func(command *Command) error {
+			ran = true
+			return nil
+		}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.SetWriters(b, b)
+
+		_, err := subject.ExecuteWith([]string{"magic", "wish", "--loud", "--print-config"})
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		if ran {
+			t.Errorf("want command not to run")
+		}
+
+		want := [][]string{
+			{"--config", subject.ConfigFile, "default"},
+			{"--count", "3", "config", "(" + subject.ConfigFile + ")"},
+			{"--loud", "true", "argv"},
+			{"--name", "jafar", "env", "(MAGIC_NAME)"},
+			{"--who", "default"},
+		}
+		lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+		if len(lines) != len(want) {
+			t.Fatalf("want %d lines, got %s", len(want), b.String())
+		}
+
+		for i, line := range lines {
+			got := strings.Fields(line)
+			if strings.Join(got, " ") != strings.Join(want[i], " ") {
+				t.Errorf("want %v, got %v", want[i], got)
+			}
+		}
+	})
+
+	t.Run("validate json output", func(t *testing.T) {
+		b := bytes.NewBufferString("")
+		file := filepath.Join(t.TempDir(), "magic.conf")
+		if err := ioutil.WriteFile(file, []byte("[magic wish]\ncount = 3\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.AutomaticEnv = true
+		subject.RootCommand.PersistentFlags.String("name", "genie", "your name")
+		subject.RootCommand.PersistentFlags.String("token", "", "api token")
+		subject.RootCommand.SecretFlag("token")
+		subject.SetConfigFile(file)
+		subject.EnablePrintConfig()
+		wish := NewCommand("wish", true)
+		wish.Flags.Int("count", 1, "how many wishes")
+		wish.Flags.Bool("loud", false, "wish loudly")
+		wish.Flags.String("who", "", "who is wishing")
+		wish.Args = []*Arg{{Name: "wish", Required: true}}
+		wish.Run = func(command *Command) error {
+			return nil
+		}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.SetWriters(b, b)
+
+		_, err := subject.ExecuteWith([]string{"magic", "--print-config=json", "wish", "--who", "aladdin"})
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		want := `{
+    "flag": "who",
+    "value": "aladdin",
+    "source": "argv"
+  }`
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("want %s, got %s", want, b.String())
+		}
+
+		if !strings.Contains(b.String(), `"from": "`+subject.ConfigFile+`"`) {
+			t.Errorf("want config file, got %s", b.String())
+		}
+	})
+
+	t.Run("validate invalid format", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "magic.conf")
+		if err := ioutil.WriteFile(file, []byte("[magic wish]\ncount = 3\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.AutomaticEnv = true
+		subject.RootCommand.PersistentFlags.String("name", "genie", "your name")
+		subject.RootCommand.PersistentFlags.String("token", "", "api token")
+		subject.RootCommand.SecretFlag("token")
+		subject.SetConfigFile(file)
+		subject.EnablePrintConfig()
+		wish := NewCommand("wish", true)
+		wish.Flags.Int("count", 1, "how many wishes")
+		wish.Flags.Bool("loud", false, "wish loudly")
+		wish.Flags.String("who", "", "who is wishing")
+		wish.Args = []*Arg{{Name: "wish", Required: true}}
+		wish.Run = func(command *Command) error {
+			return nil
+		}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.SetWriters(bytes.NewBufferString(""), bytes.NewBufferString(""))
+
+		_, err := subject.ExecuteWith([]string{"magic", "wish", "--print-config=yaml"})
+		if err == nil {
+			t.Errorf("want error, got nil")
+		}
+	})
+}
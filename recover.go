@@ -0,0 +1,86 @@
+package genie
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+// PanicError is returned when panic recovery is enabled and a command panics during execution.
+type PanicError struct {
+	Path   string      //path of the command that panicked
+	Value  interface{} //the value passed to panic
+	Stack  []byte      //the stack captured when the panic was recovered
+	Report string      //the file the crash report was written to, if any
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic in %q: %v", e.Path, e.Value)
+}
+
+// Unwrap returns the panic value if it was an error.
+func (e *PanicError) Unwrap() error {
+	if err, ok := e.Value.(error); ok {
+		return err
+	}
+
+	return nil
+}
+
+// recoversPanics returns true if panics should be recovered, either because the command or executing Lamp asked.
+func (c *Command) recoversPanics() bool {
+	return c.RecoverPanics || (c.lamp != nil && c.lamp.RecoverPanics)
+}
+
+// recoverPanic converts a panic into a PanicError stored in err, telling the user something went wrong on the
+// command's error writer. It must be deferred directly to recover the panic.
+func (c *Command) recoverPanic(err *error) {
+	value := recover()
+	if value == nil {
+		return
+	}
+
+	panicErr := c.newPanicError(value)
+	panicErr.tell(c.Err)
+	*err = panicErr
+}
+
+// newPanicError returns a PanicError for the recovered value, writing the crash report if the Lamp asked for one.
+func (c *Command) newPanicError(value interface{}) *PanicError {
+	panicErr := &PanicError{Path: c.pathOrName(), Value: value, Stack: debug.Stack()}
+	if c.lamp != nil && c.lamp.CrashReport != "" {
+		if writeErr := os.WriteFile(c.lamp.CrashReport, panicErr.report(), 0o600); writeErr == nil {
+			panicErr.Report = c.lamp.CrashReport
+		}
+	}
+
+	return panicErr
+}
+
+// tell writes a friendly message about the panic to w, if w is not nil.
+func (e *PanicError) tell(w io.Writer) {
+	if w == nil {
+		return
+	}
+
+	_, _ = fmt.Fprintf(w, "%s: something went wrong, this is a bug: %v\n", e.Path, e.Value)
+	if e.Report != "" {
+		_, _ = fmt.Fprintf(w, "a crash report was written to %s\n", e.Report)
+	}
+}
+
+// report returns the full crash report for the panic.
+func (e *PanicError) report() []byte {
+	var b bytes.Buffer
+	_, _ = fmt.Fprintf(&b, "command: %s\n", e.Path)
+	_, _ = fmt.Fprintf(&b, "panic: %v\n", e.Value)
+	_, _ = fmt.Fprintf(&b, "time: %s\n", time.Now().Format(time.RFC3339))
+	_, _ = fmt.Fprintf(&b, "go: %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	_, _ = fmt.Fprintf(&b, "args: %q\n\n", os.Args)
+	b.Write(e.Stack)
+	return b.Bytes()
+}
@@ -0,0 +1,229 @@
+package genie
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPanicError_Error(t *testing.T) {
+	want := `panic in "magic wish": boom`
+	subject := &PanicError{Path: "magic wish", Value: "boom"}
+	if subject.Error() != want {
+		t.Errorf("want %s, got %s", want, subject.Error())
+	}
+
+	if subject.Unwrap() != nil {
+		t.Errorf("want nil, got %s", subject.Unwrap())
+	}
+
+	wrapped := errors.New("boom")
+	subject.Value = wrapped
+	if !errors.Is(subject, wrapped) {
+		t.Errorf("want %s, got %s", wrapped, subject)
+	}
+}
+
+func TestLamp_ExecuteWith_recoverPanics(t *testing.T) {
+	t.Run("validate panic in run is returned as panic error", func(t *testing.T) {
+		b := bytes.NewBufferString("")
+		subject := NewLamp("magic", "0.0.0", true)
+		wish := NewCommand("wish", true)
+		wish.Run = func(command *Command) error {
+			panic("boom")
+		}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.SetWriters(b, b)
+		subject.RecoverPanics = true
+
+		_, err := subject.ExecuteWith([]string{"magic", "wish"})
+		var got *PanicError
+		if !errors.As(err, &got) {
+			t.Fatalf("want panic error, got %v", err)
+		}
+
+		if got.Path != "magic wish" || got.Value != "boom" {
+			t.Errorf("want magic wish and boom, got %s and %v", got.Path, got.Value)
+		}
+
+		if !strings.Contains(string(got.Stack), "recover_test.go") {
+			t.Errorf("want stack to include panic site, got %s", got.Stack)
+		}
+
+		want := "magic wish: something went wrong, this is a bug: boom\n"
+		if b.String() != want {
+			t.Errorf("want %s, got %s", want, b.String())
+		}
+	})
+
+	t.Run("validate panic in check is recovered", func(t *testing.T) {
+		subject := NewLamp("magic", "0.0.0", true)
+		wish := NewCommand("wish", true)
+		wish.Run = func(command *Command) error {
+			panic("boom")
+		}
+		wish.Check = func(command *Command) error {
+			panic(errors.New("check boom"))
+		}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.SetWriters(bytes.NewBufferString(""), bytes.NewBufferString(""))
+		subject.RecoverPanics = true
+
+		_, err := subject.ExecuteWith([]string{"magic", "wish"})
+		var got *PanicError
+		if !errors.As(err, &got) {
+			t.Errorf("want panic error, got %v", err)
+		}
+	})
+
+	t.Run("validate cleanups run when panic is recovered", func(t *testing.T) {
+		cleaned := false
+		subject := NewLamp("magic", "0.0.0", true)
+		wish := NewCommand("wish", true)
+		wish.Run = func(command *Command) error {
+			command.OnCleanup(func() {
+				cleaned = true
+			})
+			panic("boom")
+		}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.SetWriters(bytes.NewBufferString(""), bytes.NewBufferString(""))
+		subject.RecoverPanics = true
+
+		_, _ = subject.ExecuteWith([]string{"magic", "wish"})
+		if !cleaned {
+			t.Errorf("want cleanup to run")
+		}
+	})
+
+	t.Run("validate crash report is written", func(t *testing.T) {
+		b := bytes.NewBufferString("")
+		subject := NewLamp("magic", "0.0.0", true)
+		wish := NewCommand("wish", true)
+		wish.Run = func(command *Command) error {
+			panic("boom")
+		}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.SetWriters(b, b)
+		subject.RecoverPanics = true
+		subject.CrashReport = filepath.Join(t.TempDir(), "crash.txt")
+
+		_, err := subject.ExecuteWith([]string{"magic", "wish"})
+		var got *PanicError
+		if !errors.As(err, &got) {
+			t.Fatalf("want panic error, got %v", err)
+		}
+
+		if got.Report != subject.CrashReport {
+			t.Errorf("want %s, got %s", subject.CrashReport, got.Report)
+		}
+
+		report, readErr := ioutil.ReadFile(subject.CrashReport)
+		if readErr != nil {
+			t.Fatalf("want nil, got %s", readErr)
+		}
+
+		if !strings.HasPrefix(string(report), "command: magic wish\npanic: boom\n") {
+			t.Errorf("want report header, got %s", report)
+		}
+
+		if !strings.Contains(b.String(), "a crash report was written to "+subject.CrashReport) {
+			t.Errorf("want crash report location, got %s", b.String())
+		}
+	})
+
+	t.Run("validate main does not repeat the panic message", func(t *testing.T) {
+		b := bytes.NewBufferString("")
+		subject := NewLamp("magic", "0.0.0", true)
+		wish := NewCommand("wish", true)
+		wish.Run = func(command *Command) error {
+			panic("boom")
+		}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.SetWriters(b, b)
+		subject.RecoverPanics = true
+		code := -1
+		subject.Exit = func(c int) {
+			code = c
+		}
+
+		oldArgs := os.Args
+		defer func() { os.Args = oldArgs }()
+		os.Args = []string{"magic", "wish"}
+
+		subject.Main()
+
+		if code != ExitCodeError {
+			t.Errorf("want %d, got %d", ExitCodeError, code)
+		}
+
+		if strings.Count(b.String(), "boom") != 1 {
+			t.Errorf("want one message, got %s", b.String())
+		}
+	})
+
+	t.Run("validate main reports fan out items that panicked", func(t *testing.T) {
+		b := bytes.NewBufferString("")
+		subject := NewLamp("magic", "0.0.0", true)
+		wish := NewCommand("wish", true)
+		wish.SetFanOut(&FanOut{
+			Each: func(command *Command, item string, out io.Writer) error {
+				panic("boom")
+			},
+		})
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.SetWriters(b, b)
+		subject.RecoverPanics = true
+		subject.Exit = func(c int) {}
+		subject.SetReader(strings.NewReader("a\n"), AlwaysPiped)
+
+		oldArgs := os.Args
+		defer func() { os.Args = oldArgs }()
+		os.Args = []string{"magic", "wish"}
+
+		subject.Main()
+
+		if !strings.Contains(b.String(), "something went wrong") || !strings.Contains(b.String(), "failed for 1 of 1 items") {
+			t.Errorf("want panic message and failed items, got %s", b.String())
+		}
+	})
+
+	t.Run("validate panics are not recovered by default", func(t *testing.T) {
+		subject := NewLamp("magic", "0.0.0", true)
+		wish := NewCommand("wish", true)
+		wish.Run = func(command *Command) error {
+			panic("boom")
+		}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.SetWriters(bytes.NewBufferString(""), bytes.NewBufferString(""))
+		defer func() {
+			if recover() == nil {
+				t.Errorf("want panic")
+			}
+		}()
+
+		_, _ = subject.ExecuteWith([]string{"magic", "wish"})
+	})
+}
+
+func TestCommand_run_recoverPanics(t *testing.T) {
+	subject := NewCommand("wish", true)
+	subject.SetErr(bytes.NewBufferString(""))
+	subject.RecoverPanics = true
+	subject.Run = func(command *Command) error {
+		var m map[string]int
+		m["boom"]++
+		return nil
+	}
+
+	err := subject.run([]string{})
+	var got *PanicError
+	if !errors.As(err, &got) {
+		t.Errorf("want panic error, got %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package genie
+
+import (
+	"fmt"
+	"strings"
+)
+
+var ErrFlagRequired = Error("required flag not provided")
+
+// MissingFlagsError is returned when one or more required flags were not provided, it wraps ErrFlagRequired.
+type MissingFlagsError struct {
+	Path  string   //path of the command the flags are required for
+	Flags []string //names of the missing flags, without dashes
+	Env   []string //the environment variable that could have been set for each missing flag, if any
+}
+
+func (e *MissingFlagsError) Error() string {
+	missing := make([]string, len(e.Flags))
+	for i, name := range e.Flags {
+		missing[i] = dashed(name)
+		if i < len(e.Env) && e.Env[i] != "" {
+			missing[i] = fmt.Sprintf("%s (or %s)", missing[i], e.Env[i])
+		}
+	}
+
+	if len(missing) == 1 {
+		return fmt.Sprintf("required flag %s not provided for %q", missing[0], e.Path)
+	}
+
+	return fmt.Sprintf("required flags %s not provided for %q", strings.Join(missing, ", "), e.Path)
+}
+
+func (e *MissingFlagsError) Unwrap() error {
+	return ErrFlagRequired
+}
+
+func (e *MissingFlagsError) As(target interface{}) bool {
+	token := ""
+	if len(e.Flags) > 0 {
+		token = dashed(e.Flags[0])
+	}
+
+	return asUsageError(target, &UsageError{Path: e.Path, Token: token, Err: e})
+}
+
+// RequireFlags marks the named flags as required, the command won't run unless each is provided on the command line,
+// or set by an environment variable or config file. Persistent flags required on a command are required for all of
+// its subcommands.
+func (c *Command) RequireFlags(names ...string) {
+	c.requiredFlags = append(c.requiredFlags, names...)
+}
+
+// flagIsRequired returns true if the named flag is required for the command.
+func (c *Command) flagIsRequired(name string) bool {
+	name = c.canonicalFlag(name)
+	for cmd := c; cmd != nil; cmd = cmd.parent {
+		for _, required := range cmd.requiredFlags {
+			if cmd.canonicalFlag(required) == name && (cmd == c || cmd.declaresPersistent(name)) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// requiredMarker returns the marker added to the usage of required flags.
+func (c *Command) requiredMarker(name string) string {
+	if c.flagIsRequired(name) {
+		return " (required)"
+	}
+
+	return ""
+}
+
+// checkRequiredFlags returns a MissingFlagsError listing every required flag that wasn't set, it must be called after
+// the command line, environment, and config file have been applied.
+func (c *Command) checkRequiredFlags() error {
+	if c.Flags == nil {
+		return nil
+	}
+
+	missing := &MissingFlagsError{Path: c.pathOrName()}
+	seen := make(map[string]bool)
+	for cmd := c; cmd != nil; cmd = cmd.parent {
+		for _, required := range cmd.requiredFlags {
+			name := cmd.canonicalFlag(required)
+			if seen[name] || !c.flagIsRequired(name) || c.Flags.Lookup(name) == nil {
+				continue
+			}
+			seen[name] = true
+
+			if _, set := c.flagOrigins[name]; set {
+				continue
+			}
+
+			variable, _ := c.envVar(name)
+			missing.Flags = append(missing.Flags, name)
+			missing.Env = append(missing.Env, variable)
+		}
+	}
+
+	if len(missing.Flags) == 0 {
+		return nil
+	}
+
+	return missing
+}
@@ -0,0 +1,194 @@
+package genie
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMissingFlagsError_Error(t *testing.T) {
+	t.Run("validate single flag", func(t *testing.T) {
+		want := `required flag --name not provided for "magic wish"`
+		subject := &MissingFlagsError{Path: "magic wish", Flags: []string{"name"}}
+		if subject.Error() != want {
+			t.Errorf("want %s, got %s", want, subject)
+		}
+	})
+
+	t.Run("validate multiple flags with env", func(t *testing.T) {
+		want := `required flags --name (or MAGIC_WISH_NAME), -c not provided for "magic wish"`
+		subject := &MissingFlagsError{Path: "magic wish", Flags: []string{"name", "c"}, Env: []string{"MAGIC_WISH_NAME", ""}}
+		if subject.Error() != want {
+			t.Errorf("want %s, got %s", want, subject)
+		}
+	})
+}
+
+func TestCommand_RequireFlags(t *testing.T) {
+	t.Run("validate all missing flags are reported", func(t *testing.T) {
+		want := `required flags --name, --count, --token not provided for "magic wish"`
+		ran := false
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.PersistentFlags.String("token", "", "api token")
+		subject.RootCommand.RequireFlags("token")
+		wish := NewCommand("wish", true)
+		wish.Flags.String("name", "", "your name")
+		wish.Flags.Int("count", 1, "how many wishes")
+		wish.Flags.Bool("loud", false, "wish loudly")
+		wish.FlagShorthand("name", "n")
+		wish.RequireFlags("name", "count")
+		wish.Run = func(command *Command) error {
+			return nil
+		}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.SetWriters(bytes.NewBufferString(""), bytes.NewBufferString(""))
+		wish.Check = func(command *Command) error {
+			ran = true
+			return nil
+		}
+
+		_, err := subject.ExecuteWith([]string{"magic", "wish"})
+		var got *MissingFlagsError
+		if !errors.As(err, &got) {
+			t.Fatalf("want missing flags error, got %v", err)
+		}
+
+		if got.Error() != want {
+			t.Errorf("want %s, got %s", want, got)
+		}
+
+		if !errors.Is(err, ErrFlagRequired) || !IsUsageError(err) || ExitCode(err) != ExitCodeUsage {
+			t.Errorf("want required usage error, got %s", err)
+		}
+
+		if ran {
+			t.Errorf("want check not to run")
+		}
+	})
+
+	t.Run("validate provided flags satisfy requirement", func(t *testing.T) {
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.PersistentFlags.String("token", "", "api token")
+		subject.RootCommand.RequireFlags("token")
+		wish := NewCommand("wish", true)
+		wish.Flags.String("name", "", "your name")
+		wish.Flags.Int("count", 1, "how many wishes")
+		wish.Flags.Bool("loud", false, "wish loudly")
+		wish.FlagShorthand("name", "n")
+		wish.RequireFlags("name", "count")
+		wish.Run = func(command *Command) error {
+			return nil
+		}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.SetWriters(bytes.NewBufferString(""), bytes.NewBufferString(""))
+
+		_, err := subject.ExecuteWith([]string{"magic", "--token", "abc", "wish", "-n", "jafar", "--count", "2"})
+		if err != nil {
+			t.Errorf("want nil, got %s", err)
+		}
+	})
+
+	t.Run("validate env and config satisfy requirement", func(t *testing.T) {
+		t.Setenv("MAGIC_TOKEN", "abc")
+		file := filepath.Join(t.TempDir(), "magic.conf")
+		if err := ioutil.WriteFile(file, []byte("[magic wish]\nname = jafar\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.PersistentFlags.String("token", "", "api token")
+		subject.RootCommand.RequireFlags("token")
+		wish := NewCommand("wish", true)
+		wish.Flags.String("name", "", "your name")
+		wish.Flags.Int("count", 1, "how many wishes")
+		wish.Flags.Bool("loud", false, "wish loudly")
+		wish.FlagShorthand("name", "n")
+		wish.RequireFlags("name", "count")
+		wish.Run = func(command *Command) error {
+			return nil
+		}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.SetWriters(bytes.NewBufferString(""), bytes.NewBufferString(""))
+		subject.AutomaticEnv = true
+		subject.SetConfigFile(file)
+
+		_, err := subject.ExecuteWith([]string{"magic", "wish"})
+		want := `required flag --count (or MAGIC_WISH_COUNT) not provided for "magic wish"`
+		if err == nil || err.Error() != want {
+			t.Errorf("want %s, got %v", want, err)
+		}
+	})
+
+	t.Run("validate persistent requirement does not apply to other commands local flags", func(t *testing.T) {
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.PersistentFlags.String("token", "", "api token")
+		subject.RootCommand.RequireFlags("token")
+		wish := NewCommand("wish", true)
+		wish.Flags.String("name", "", "your name")
+		wish.Flags.Int("count", 1, "how many wishes")
+		wish.Flags.Bool("loud", false, "wish loudly")
+		wish.FlagShorthand("name", "n")
+		wish.RequireFlags("name", "count")
+		wish.Run = func(command *Command) error {
+			return nil
+		}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.SetWriters(bytes.NewBufferString(""), bytes.NewBufferString(""))
+		subject.RootCommand.Run = func(command *Command) error {
+			return nil
+		}
+
+		_, err := subject.ExecuteWith([]string{"magic"})
+		want := `required flag --token not provided for "magic"`
+		if err == nil || err.Error() != want {
+			t.Errorf("want %s, got %v", want, err)
+		}
+	})
+}
+
+func TestCommand_RequireFlags_usage(t *testing.T) {
+	subject := NewLamp("magic", "0.0.0", true)
+	subject.RootCommand.PersistentFlags.String("token", "", "api token")
+	subject.RootCommand.RequireFlags("token")
+	wish := NewCommand("wish", true)
+	wish.Flags.String("name", "", "your name")
+	wish.Flags.Int("count", 1, "how many wishes")
+	wish.Flags.Bool("loud", false, "wish loudly")
+	wish.FlagShorthand("name", "n")
+	wish.RequireFlags("name", "count")
+	wish.Run = func(command *Command) error {
+		return nil
+	}
+	subject.RootCommand.SubCommands = []*Command{wish}
+	subject.SetWriters(bytes.NewBufferString(""), bytes.NewBufferString(""))
+	subject.RootCommand.AnchorPaths()
+
+	t.Run("validate usage", func(t *testing.T) {
+		got := DefaultCommandUsageFunc(wish)
+		for _, want := range []string{"your name (required)\n", "how many wishes (default 1) (required)\n", "api token (required)\n", "wish loudly (default false)\n"} {
+			if !strings.Contains(got, want) {
+				t.Errorf("want %q, got %s", want, got)
+			}
+		}
+	})
+
+	t.Run("validate merged usage", func(t *testing.T) {
+		wish.MergeFlagUsage = true
+		defer func() { wish.MergeFlagUsage = false }()
+		got := DefaultCommandUsageFunc(wish)
+		if !strings.Contains(got, "your name (required)\n") {
+			t.Errorf("want required, got %s", got)
+		}
+	})
+
+	t.Run("validate marked usage", func(t *testing.T) {
+		got := DefaultCommandUsageMarkedFunc(wish)
+		for _, want := range []string{"your name (required)\n", "api token (required)\n"} {
+			if !strings.Contains(got, want) {
+				t.Errorf("want %q, got %s", want, got)
+			}
+		}
+	})
+}
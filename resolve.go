@@ -0,0 +1,156 @@
+package genie
+
+import (
+	"flag"
+	"strings"
+)
+
+// boolFlag is implemented by flag values that don't require a value, this matches the flag package's definition.
+type boolFlag interface {
+	flag.Value
+	IsBoolFlag() bool
+}
+
+// resolve walks the provided arguments (which should not contain the interface name) to find the command to execute.
+// Flags may be provided before, between, or after command names. Flags belonging to an intermediate command in the path
+// are parsed by that command, everything else is returned in order for the resolved command to parse: its flags, then
+// any arguments. Resolution stops at the first argument that is not a flag or known command, or at "--". If a command
+// can't be found the command it was provided to is returned along with the error.
+func (l *Lamp) resolve(args []string) (*Command, []string, error) {
+	current := l.RootCommand
+	var pathFlags []string
+	var rest []string
+	localFlags := make(map[*Command][]string)
+	var path []*Command
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = args[i:]
+			break
+		}
+
+		if isFlagArg(arg) && current.flagSyntax(l) == FlagSyntaxGNU {
+			//each translated token stands alone, so it can be given to the command it belongs to
+			lookup := func(name string) *flag.Flag {
+				f, _ := current.lookupFlag(name)
+				return f
+			}
+			tokens, consumed := current.gnuTokens(lookup, args, i)
+			for _, token := range tokens {
+				name, _ := flagArgName(token)
+				if _, local := current.lookupFlag(name); local {
+					localFlags[current] = append(localFlags[current], token)
+				} else {
+					pathFlags = append(pathFlags, token)
+				}
+			}
+			i += consumed - 1
+			continue
+		}
+
+		if isFlagArg(arg) {
+			name, hasValue := flagArgName(arg)
+			tokens := []string{arg}
+			f, local := current.lookupFlag(name)
+			if f != nil && !hasValue && !isBoolFlag(f) && i+1 < len(args) {
+				tokens = append(tokens, args[i+1])
+				i++
+			}
+
+			if local {
+				localFlags[current] = append(localFlags[current], tokens...)
+			} else {
+				pathFlags = append(pathFlags, tokens...)
+			}
+			continue
+		}
+
+		sc, found, err := l.findSubCommand(current, arg)
+		if err != nil {
+			return current, nil, err
+		}
+		if !found {
+			//if the command can't be run, or doesn't expect arguments and there's a similar command, the argument must
+			//be a mistyped command, otherwise we have arguments to pass on. "-" is the stdin convention, never a command
+			if len(current.SubCommands) > 0 && arg != "-" {
+				runnable := current.Run != nil || current.FanOut != nil
+				if !runnable {
+					return current, nil, &CommandNotFoundError{Path: current.pathOrName(), Name: arg, Suggestions: current.suggestSubCommands(arg)}
+				}
+				if !current.acceptsArgs() {
+					if suggestions := current.suggestSubCommands(arg); len(suggestions) > 0 {
+						return current, nil, &CommandNotFoundError{Path: current.pathOrName(), Name: arg, Suggestions: suggestions}
+					}
+				}
+			}
+			rest = args[i:]
+			break
+		}
+
+		path = append(path, current)
+		current = sc
+	}
+
+	//intermediate commands parse their own flags, so any values they bind to are set before the command executes
+	for _, c := range path {
+		tokens, ok := localFlags[c]
+		if !ok {
+			continue
+		}
+		if err := c.Flags.Parse(tokens); err != nil {
+			return c, nil, newFlagError(c, tokens, err)
+		}
+	}
+
+	commandArgs := make([]string, 0, len(args))
+	commandArgs = append(commandArgs, localFlags[current]...)
+	commandArgs = append(commandArgs, pathFlags...)
+	commandArgs = append(commandArgs, rest...)
+
+	return current, commandArgs, nil
+}
+
+// acceptsArgs returns true if the command declares it takes positional arguments, through Args, ValidateArgs or ArgInfo.
+func (c *Command) acceptsArgs() bool {
+	return len(c.Args) > 0 || c.ValidateArgs != nil || c.ArgInfo != ""
+}
+
+// lookupFlag finds the named flag for the command, local is true if the flag is defined on the command's own Flags
+// rather than being a persistent flag of the command or one of its parents.
+func (c *Command) lookupFlag(name string) (*flag.Flag, bool) {
+	if c.Flags != nil && !c.globalFlags[name] {
+		if f := c.Flags.Lookup(name); f != nil {
+			return f, true
+		}
+	}
+
+	for cmd := c; cmd != nil; cmd = cmd.parent {
+		if cmd.PersistentFlags == nil {
+			continue
+		}
+		if f := cmd.PersistentFlags.Lookup(name); f != nil {
+			return f, false
+		}
+	}
+
+	return nil, false
+}
+
+func isFlagArg(arg string) bool {
+	return len(arg) > 1 && arg[0] == '-'
+}
+
+// flagArgName returns the name of the flag provided in arg, and true if the value was provided with it (-flag=value).
+func flagArgName(arg string) (string, bool) {
+	name := strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+	if i := strings.Index(name, "="); i >= 0 {
+		return name[:i], true
+	}
+
+	return name, false
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(boolFlag)
+	return ok && b.IsBoolFlag()
+}
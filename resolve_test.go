@@ -0,0 +1,257 @@
+package genie
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func TestLamp_resolve(t *testing.T) {
+	t.Run("validate flags before and between command names", func(t *testing.T) {
+		wantArgs := []string{"-t", "x", "--verbose", "arg"}
+		test := ""
+		verbose := false
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.PersistentFlags.BoolVar(&verbose, "verbose", false, "verbose output")
+		sub := NewCommand("sub", true)
+		sub.Flags.StringVar(&test, "t", "", "the test flag")
+		sub.Run = func(command *Command) error {
+			return nil
+		}
+		wish := NewCommand("wish", true)
+		wish.SubCommands = []*Command{sub}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.RootCommand.AnchorPaths()
+
+		got, gotArgs, err := subject.resolve([]string{"--verbose", "wish", "sub", "-t", "x", "arg"})
+		if err != nil {
+			t.Fatalf("[err] want nil, got %s", err)
+		}
+		if got.Name != "sub" {
+			t.Errorf("want sub, got %s", got.Name)
+		}
+		if !reflect.DeepEqual(gotArgs, wantArgs) {
+			t.Errorf("want %v, got %v", wantArgs, gotArgs)
+		}
+
+		err = got.run(gotArgs)
+		if err != nil {
+			t.Errorf("[err] want nil, got %s", err)
+		}
+		if test != "x" {
+			t.Errorf("want x, got %s", test)
+		}
+		if !verbose {
+			t.Error("want true, got false")
+		}
+	})
+
+	t.Run("validate intermediate command flags are parsed by the intermediate command", func(t *testing.T) {
+		wantArgs := []string{"-t", "x"}
+		count := 0
+		name := ""
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.Flags.IntVar(&count, "count", 0, "the count")
+		sub := NewCommand("sub", true)
+		sub.Flags.String("t", "", "the test flag")
+		wish := NewCommand("wish", true)
+		wish.Flags.StringVar(&name, "name", "", "the name")
+		wish.SubCommands = []*Command{sub}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.RootCommand.AnchorPaths()
+
+		got, gotArgs, err := subject.resolve([]string{"-count", "3", "wish", "--name=genie", "sub", "-t", "x"})
+		if err != nil {
+			t.Fatalf("[err] want nil, got %s", err)
+		}
+		if got.Name != "sub" {
+			t.Errorf("want sub, got %s", got.Name)
+		}
+		if !reflect.DeepEqual(gotArgs, wantArgs) {
+			t.Errorf("want %v, got %v", wantArgs, gotArgs)
+		}
+		if count != 3 {
+			t.Errorf("want 3, got %d", count)
+		}
+		if name != "genie" {
+			t.Errorf("want genie, got %s", name)
+		}
+	})
+
+	t.Run("validate resolution stops at first argument", func(t *testing.T) {
+		wantArgs := []string{"--name", "genie", "arg", "sub", "-t", "x"}
+		subject := NewLamp("magic", "0.0.0", true)
+		wish := NewCommand("wish", true)
+		wish.Flags.String("name", "", "the name")
+		wish.Run = func(command *Command) error {
+			return nil
+		}
+		wish.SubCommands = []*Command{NewCommand("sub", true)}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.RootCommand.AnchorPaths()
+
+		got, gotArgs, err := subject.resolve([]string{"wish", "--name", "genie", "arg", "sub", "-t", "x"})
+		if err != nil {
+			t.Fatalf("[err] want nil, got %s", err)
+		}
+		if got.Name != "wish" {
+			t.Errorf("want wish, got %s", got.Name)
+		}
+		if !reflect.DeepEqual(gotArgs, wantArgs) {
+			t.Errorf("want %v, got %v", wantArgs, gotArgs)
+		}
+	})
+
+	t.Run("validate resolution stops at terminator", func(t *testing.T) {
+		wantArgs := []string{"--verbose", "--", "sub"}
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.PersistentFlags.Bool("verbose", false, "verbose output")
+		wish := NewCommand("wish", true)
+		wish.SubCommands = []*Command{NewCommand("sub", true)}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.RootCommand.AnchorPaths()
+
+		got, gotArgs, err := subject.resolve([]string{"wish", "--verbose", "--", "sub"})
+		if err != nil {
+			t.Fatalf("[err] want nil, got %s", err)
+		}
+		if got.Name != "wish" {
+			t.Errorf("want wish, got %s", got.Name)
+		}
+		if !reflect.DeepEqual(gotArgs, wantArgs) {
+			t.Errorf("want %v, got %v", wantArgs, gotArgs)
+		}
+	})
+
+	t.Run("validate bool flags and unknown flags do not consume the next argument", func(t *testing.T) {
+		wantArgs := []string{"-nope", "--verbose"}
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.PersistentFlags.Bool("verbose", false, "verbose output")
+		wish := NewCommand("wish", true)
+		wish.SubCommands = []*Command{NewCommand("sub", true)}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.RootCommand.AnchorPaths()
+
+		got, gotArgs, err := subject.resolve([]string{"-nope", "wish", "--verbose", "sub"})
+		if err != nil {
+			t.Fatalf("[err] want nil, got %s", err)
+		}
+		if got.Name != "sub" {
+			t.Errorf("want sub, got %s", got.Name)
+		}
+		if !reflect.DeepEqual(gotArgs, wantArgs) {
+			t.Errorf("want %v, got %v", wantArgs, gotArgs)
+		}
+	})
+
+	t.Run("validate error if command not found and command not runnable", func(t *testing.T) {
+		want := ErrCommandNotFound
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.PersistentFlags.Bool("verbose", false, "verbose output")
+		subject.RootCommand.SubCommands = []*Command{NewCommand("wish", true)}
+		subject.RootCommand.AnchorPaths()
+
+		_, _, got := subject.resolve([]string{"--verbose", "nope", "sub"})
+		if !errors.Is(got, want) {
+			t.Errorf("want %s, got %s", want, got)
+		}
+	})
+
+	t.Run("validate error if intermediate command flags are invalid", func(t *testing.T) {
+		want := "invalid value \"three\" for flag --count: parse error"
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.Flags.Int("count", 0, "the count")
+		subject.RootCommand.SubCommands = []*Command{NewCommand("wish", true)}
+		subject.RootCommand.AnchorPaths()
+
+		_, _, got := subject.resolve([]string{"--count", "three", "wish"})
+		if got == nil {
+			t.Fatal("want error, got nil")
+		}
+		if got.Error() != want {
+			t.Errorf("want %s, got %s", want, got)
+		}
+	})
+}
+
+func TestLamp_ExecuteWith_interspersed(t *testing.T) {
+	t.Run("validate flags before and between subcommand names", func(t *testing.T) {
+		b := bytes.NewBufferString("")
+		want := "sub ran with x"
+		test := ""
+		verbose := false
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.PersistentFlags.BoolVar(&verbose, "verbose", false, "verbose output")
+		sub := NewCommand("sub", true)
+		sub.Flags.StringVar(&test, "t", "", "the test flag")
+		sub.Run = func(command *Command) error {
+			command.Out.Write([]byte("sub ran with " + test))
+			return nil
+		}
+		wish := NewCommand("wish", true)
+		wish.SubCommands = []*Command{sub}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.SetWriters(b, b)
+
+		got, err := subject.ExecuteWith([]string{"magic", "--verbose", "wish", "sub", "-t", "x"})
+		if err != nil {
+			t.Errorf("[err] want nil, got %s", err)
+		}
+		if got.Path() != "magic wish sub" {
+			t.Errorf("want magic wish sub, got %s", got.Path())
+		}
+		if !verbose {
+			t.Error("want true, got false")
+		}
+		gotOut, err := ioutil.ReadAll(b)
+		if err != nil {
+			t.Errorf("[err] want nil, got %s", err)
+		}
+		if string(gotOut) != want {
+			t.Errorf("want: %s, got %s", want, string(gotOut))
+		}
+	})
+}
+
+func Test_flagArgName(t *testing.T) {
+	testCases := []struct {
+		arg       string
+		wantName  string
+		wantValue bool
+	}{
+		{arg: "-t", wantName: "t"},
+		{arg: "--test", wantName: "test"},
+		{arg: "--test=x", wantName: "test", wantValue: true},
+		{arg: "-test=", wantName: "test", wantValue: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.arg, func(t *testing.T) {
+			gotName, gotValue := flagArgName(tc.arg)
+			if gotName != tc.wantName {
+				t.Errorf("want %s, got %s", tc.wantName, gotName)
+			}
+			if gotValue != tc.wantValue {
+				t.Errorf("want %t, got %t", tc.wantValue, gotValue)
+			}
+		})
+	}
+}
+
+func Test_isBoolFlag(t *testing.T) {
+	t.Run("validate bool flags", func(t *testing.T) {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.Bool("b", false, "bool")
+		fs.String("s", "", "string")
+
+		if !isBoolFlag(fs.Lookup("b")) {
+			t.Error("want true, got false")
+		}
+		if isBoolFlag(fs.Lookup("s")) {
+			t.Error("want false, got true")
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package genie
+
+import (
+	"fmt"
+	"sort"
+)
+
+// FlagShorthand adds a single letter shorthand for the named flag, which can be one of the command's Flags or
+// PersistentFlags. The shorthand is the same flag: providing either is reported as providing the named flag, and usage
+// shows them together. Like the flag package, it panics if the flag isn't defined or the shorthand is already in use.
+func (c *Command) FlagShorthand(name, short string) {
+	if len(short) != 1 {
+		panic(fmt.Sprintf("%s: flag shorthand %q for %s must be a single letter", c.Name, short, dashed(name)))
+	}
+
+	fs := c.Flags
+	if fs == nil || fs.Lookup(name) == nil {
+		fs = c.PersistentFlags
+	}
+	if fs == nil || fs.Lookup(name) == nil {
+		panic(fmt.Sprintf("%s: flag shorthand %q for undefined flag %s", c.Name, short, dashed(name)))
+	}
+
+	f := fs.Lookup(name)
+	fs.Var(f.Value, short, f.Usage)
+	fs.Lookup(short).DefValue = f.DefValue
+	if c.shorthands == nil {
+		c.shorthands = make(map[string]string)
+	}
+	c.shorthands[short] = name
+}
+
+// canonicalFlag returns the name of the flag the shorthand is for, or name if it's not a shorthand. Only shorthands of
+// the command's own flags and its parents' persistent flags apply, the closest definition of name wins.
+func (c *Command) canonicalFlag(name string) string {
+	for cmd := c; cmd != nil; cmd = cmd.parent {
+		if !cmd.definesFlag(name, cmd == c) {
+			continue
+		}
+		if long, ok := cmd.shorthands[name]; ok {
+			return long
+		}
+		return name
+	}
+
+	return name
+}
+
+// definesFlag returns true if the command defines the named flag in its PersistentFlags, or its Flags if local.
+func (c *Command) definesFlag(name string, local bool) bool {
+	if local && c.Flags != nil && c.Flags.Lookup(name) != nil && !c.globalFlags[name] {
+		return true
+	}
+
+	return c.PersistentFlags != nil && c.PersistentFlags.Lookup(name) != nil
+}
+
+// isShorthand returns true if name is a shorthand for another flag.
+func (c *Command) isShorthand(name string) bool {
+	return c.canonicalFlag(name) != name
+}
+
+// shorthandOf returns the shorthand of the named flag, if it has one. If more than one shorthand was added the first
+// in alphabetical order is returned.
+func (c *Command) shorthandOf(name string) string {
+	var shorts []string
+	for cmd := c; cmd != nil; cmd = cmd.parent {
+		for short, long := range cmd.shorthands {
+			if long == name && c.canonicalFlag(short) == name {
+				shorts = append(shorts, short)
+			}
+		}
+	}
+	if len(shorts) == 0 {
+		return ""
+	}
+
+	sort.Strings(shorts)
+	return shorts[0]
+}
+
+// flagUsageName returns the flag as it's displayed in usage, along with its shorthand if it has one.
+func (c *Command) flagUsageName(name string) string {
+	if short := c.shorthandOf(name); short != "" {
+		return dashed(short) + " " + dashed(name)
+	}
+
+	return dashed(name)
+}
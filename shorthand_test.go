@@ -0,0 +1,296 @@
+package genie
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCommand_FlagShorthand(t *testing.T) {
+	t.Run("validate shorthand is the same flag", func(t *testing.T) {
+		var test string
+		var verbose bool
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.PersistentFlags.BoolVar(&verbose, "verbose", false, "be verbose")
+		subject.RootCommand.FlagShorthand("verbose", "v")
+		wish := NewCommand("wish", true)
+		wish.Flags.StringVar(&test, "test", "", "the test flag")
+		wish.FlagShorthand("test", "t")
+		wish.Run = func(command *Command) error {
+			return nil
+		}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.SetWriters(bytes.NewBufferString(""), bytes.NewBufferString(""))
+
+		_, err := subject.ExecuteWith([]string{"magic", "-v", "wish", "-t", "heyo", "arg"})
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		if test != "heyo" || !verbose {
+			t.Errorf("want heyo and true, got %s and %v", test, verbose)
+		}
+
+		for _, name := range []string{"test", "t", "verbose", "v"} {
+			if !wish.FlagWasProvided(name) {
+				t.Errorf("want %s provided", name)
+			}
+			if origin, _ := wish.FlagSource(name); origin.Source != SourceArgs {
+				t.Errorf("want %s from argv, got %s", name, origin)
+			}
+		}
+
+		want := "test heyo verbose true arg"
+		if wish.FlagsAndArgs() != want {
+			t.Errorf("want %s, got %s", want, wish.FlagsAndArgs())
+		}
+	})
+
+	t.Run("validate flag and shorthand are reported once", func(t *testing.T) {
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.PersistentFlags.Bool("verbose", false, "be verbose")
+		subject.RootCommand.FlagShorthand("verbose", "v")
+		wish := NewCommand("wish", true)
+		wish.Flags.String("test", "", "the test flag")
+		wish.FlagShorthand("test", "t")
+		wish.Run = func(command *Command) error {
+			return nil
+		}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.SetWriters(bytes.NewBufferString(""), bytes.NewBufferString(""))
+
+		_, err := subject.ExecuteWith([]string{"magic", "wish", "--test", "one", "-t", "two"})
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		want := "test two"
+		if wish.FlagsAndArgs() != want {
+			t.Errorf("want %s, got %s", want, wish.FlagsAndArgs())
+		}
+
+		got := wish.EffectiveConfig()
+		if len(got) != 2 || got[0].Flag != "test" || got[1].Flag != "verbose" {
+			t.Errorf("want test and verbose, got %v", got)
+		}
+	})
+
+	t.Run("validate env binding applies to both", func(t *testing.T) {
+		t.Setenv("MAGIC_WISH_TEST", "env")
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.PersistentFlags.Bool("verbose", false, "be verbose")
+		subject.RootCommand.FlagShorthand("verbose", "v")
+		wish := NewCommand("wish", true)
+		wish.Flags.String("test", "", "the test flag")
+		wish.FlagShorthand("test", "t")
+		wish.Run = func(command *Command) error {
+			return nil
+		}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.SetWriters(bytes.NewBufferString(""), bytes.NewBufferString(""))
+		subject.AutomaticEnv = true
+
+		_, err := subject.ExecuteWith([]string{"magic", "wish"})
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		if variable, ok := wish.FlagFromEnv("t"); !ok || variable != "MAGIC_WISH_TEST" {
+			t.Errorf("want MAGIC_WISH_TEST, got %s", variable)
+		}
+
+		if strings.Contains(DefaultCommandUsageFunc(wish), "MAGIC_WISH_T ") {
+			t.Errorf("want no env var for shorthand")
+		}
+	})
+
+	t.Run("validate subcommand flag is not hidden by parent local shorthand", func(t *testing.T) {
+		var test string
+		var top bool
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.PersistentFlags.Bool("verbose", false, "be verbose")
+		subject.RootCommand.FlagShorthand("verbose", "v")
+		wish := NewCommand("wish", true)
+		wish.Flags.StringVar(&test, "test", "", "the test flag")
+		wish.FlagShorthand("test", "t")
+		wish.Run = func(command *Command) error {
+			return nil
+		}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.SetWriters(bytes.NewBufferString(""), bytes.NewBufferString(""))
+		sub := NewCommand("sub", true)
+		sub.Flags.BoolVar(&top, "t", false, "the top flag")
+		sub.Run = func(command *Command) error {
+			return nil
+		}
+		wish.SubCommands = []*Command{sub}
+
+		_, err := subject.ExecuteWith([]string{"magic", "wish", "sub", "-t"})
+		if err != nil {
+			t.Fatalf("want nil, got %s", err)
+		}
+
+		if !top || test != "" {
+			t.Errorf("want true and empty, got %v and %s", top, test)
+		}
+
+		want := "t true"
+		if sub.FlagsAndArgs() != want {
+			t.Errorf("want %s, got %s", want, sub.FlagsAndArgs())
+		}
+
+		var gotFlag bool
+		for _, v := range sub.EffectiveConfig() {
+			if v.Flag == "test" {
+				t.Errorf("want no test flag, got %v", v)
+			}
+			gotFlag = gotFlag || (v.Flag == "t" && v.Source == SourceArgs)
+		}
+		if !gotFlag {
+			t.Errorf("want t from argv, got %v", sub.EffectiveConfig())
+		}
+
+		if !strings.Contains(sub.ShowUsage(), "-t            the top flag") {
+			t.Errorf("want -t in usage, got %s", sub.ShowUsage())
+		}
+	})
+
+	t.Run("validate shorthand is chosen alphabetically", func(t *testing.T) {
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.PersistentFlags.Bool("verbose", false, "be verbose")
+		subject.RootCommand.FlagShorthand("verbose", "v")
+		wish := NewCommand("wish", true)
+		wish.Flags.String("test", "", "the test flag")
+		wish.FlagShorthand("test", "t")
+		wish.Run = func(command *Command) error {
+			return nil
+		}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.SetWriters(bytes.NewBufferString(""), bytes.NewBufferString(""))
+		wish.FlagShorthand("test", "e")
+
+		for i := 0; i < 10; i++ {
+			if got := wish.shorthandOf("test"); got != "e" {
+				t.Fatalf("want e, got %s", got)
+			}
+		}
+	})
+
+	t.Run("validate secret flag hides shorthand", func(t *testing.T) {
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.PersistentFlags.Bool("verbose", false, "be verbose")
+		subject.RootCommand.FlagShorthand("verbose", "v")
+		wish := NewCommand("wish", true)
+		wish.Flags.String("test", "", "the test flag")
+		wish.FlagShorthand("test", "t")
+		wish.Run = func(command *Command) error {
+			return nil
+		}
+		subject.RootCommand.SubCommands = []*Command{wish}
+		subject.SetWriters(bytes.NewBufferString(""), bytes.NewBufferString(""))
+		wish.SecretFlag("test")
+
+		got := DefaultCommandUsageFunc(wish)
+		if strings.Contains(got, "-t") {
+			t.Errorf("want no shorthand, got %s", got)
+		}
+	})
+
+	t.Run("validate undefined flag panics", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("want panic")
+			}
+		}()
+
+		NewCommand("wish", true).FlagShorthand("nope", "n")
+	})
+
+	t.Run("validate long shorthand panics", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("want panic")
+			}
+		}()
+
+		subject := NewCommand("wish", true)
+		subject.Flags.Bool("test", false, "test")
+		subject.FlagShorthand("test", "te")
+	})
+}
+
+func TestCommand_FlagShorthand_usage(t *testing.T) {
+	subject := NewLamp("magic", "0.0.0", true)
+	subject.RootCommand.PersistentFlags.Bool("verbose", false, "be verbose")
+	subject.RootCommand.FlagShorthand("verbose", "v")
+	wish := NewCommand("wish", true)
+	wish.Flags.String("test", "", "the test flag")
+	wish.FlagShorthand("test", "t")
+	wish.Run = func(command *Command) error {
+		return nil
+	}
+	subject.RootCommand.SubCommands = []*Command{wish}
+	subject.SetWriters(bytes.NewBufferString(""), bytes.NewBufferString(""))
+	subject.RootCommand.AnchorPaths()
+
+	t.Run("validate usage", func(t *testing.T) {
+		want := "\nFLAGS:\n--help                  display help for command\n-t --test     string    the test flag\n\nGLOBAL FLAGS:\n-v --verbose        be verbose (default false)\n"
+		got := DefaultFlagsUsageFunc(wish)
+		if "\n"+got != want {
+			t.Errorf("want %q, got %q", want, "\n"+got)
+		}
+	})
+
+	t.Run("validate merged usage", func(t *testing.T) {
+		wish.MergeFlagUsage = true
+		defer func() { wish.MergeFlagUsage = false }()
+		want := "-t --test     string    the test flag\n"
+		got := DefaultFlagsUsageFunc(wish)
+		if !strings.Contains(got, want) {
+			t.Errorf("want %q, got %q", want, got)
+		}
+	})
+
+	t.Run("validate marked usage", func(t *testing.T) {
+		want := "::FLAG::-t --test::FLAG-END::"
+		got := DefaultFlagsUsageMarkedFunc(wish)
+		if !strings.Contains(got, want) || strings.Contains(got, "::FLAG::-t::FLAG-END::") {
+			t.Errorf("want %s, got %s", want, got)
+		}
+	})
+}
+
+func TestLamp_CompletionReply_flags(t *testing.T) {
+	subject := NewLamp("magic", "0.0.0", true)
+	subject.RootCommand.PersistentFlags.Bool("verbose", false, "be verbose")
+	subject.RootCommand.FlagShorthand("verbose", "v")
+	wish := NewCommand("wish", true)
+	wish.Flags.String("test", "", "the test flag")
+	wish.FlagShorthand("test", "t")
+	wish.Run = func(command *Command) error {
+		return nil
+	}
+	subject.RootCommand.SubCommands = []*Command{wish}
+	subject.SetWriters(bytes.NewBufferString(""), bytes.NewBufferString(""))
+
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"magic wish -", "--help --test --verbose"},
+		{"magic wish --t", "--test"},
+		{"magic wish -t", "--test"},
+		{"magic -", "--help --verbose --version"},
+		{"magic --v", "--verbose --version"},
+		{"magic wish -x", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run("validate "+tt.line, func(t *testing.T) {
+			got := subject.CompletionReply(tt.line)
+			if got != tt.want {
+				t.Errorf("want %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package genie
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// cleanupsKey is used to store the cleanups for an execution in its context.
+type cleanupsKey struct{}
+
+// cleanups holds the callbacks registered via Command.OnCleanup for a single execution.
+type cleanups struct {
+	mu  sync.Mutex
+	fns []func()
+}
+
+func (c *cleanups) add(fn func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fns = append(c.fns, fn)
+}
+
+// run calls the registered callbacks in reverse order of registration, each callback is only ever called once.
+func (c *cleanups) run() {
+	c.mu.Lock()
+	fns := c.fns
+	c.fns = nil
+	c.mu.Unlock()
+
+	for i := len(fns) - 1; i >= 0; i-- {
+		fns[i]()
+	}
+}
+
+func cleanupsFromContext(ctx context.Context) (*cleanups, bool) {
+	c, ok := ctx.Value(cleanupsKey{}).(*cleanups)
+	return c, ok
+}
+
+// OnCleanup registers a function to be called once the command has finished executing. When the Lamp is handling
+// signals, cleanups are also called before the process is forced to exit. Cleanups run in reverse order of registration.
+func (c *Command) OnCleanup(fn func()) {
+	if fn == nil {
+		return
+	}
+
+	if c.ctx == nil {
+		c.ctx = context.Background()
+	}
+
+	stack, ok := cleanupsFromContext(c.ctx)
+	if !ok {
+		stack = &cleanups{}
+		c.ctx = context.WithValue(c.ctx, cleanupsKey{}, stack)
+	}
+	stack.add(fn)
+}
+
+// handleSignals installs handlers for SIGINT and SIGTERM for the lifetime of an execution. The first signal will cancel
+// the returned context, the second will run any registered cleanups and exit the process. The returned func must be
+// called once execution completes to stop handling signals.
+func (l *Lamp) handleSignals(ctx context.Context, stack *cleanups) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	done := make(chan struct{})
+
+	go func() {
+		select {
+		case <-signals:
+			cancel()
+		case <-done:
+			return
+		}
+
+		select {
+		case sig := <-signals:
+			stack.run()
+			l.exit(128 + signalNumber(sig))
+		case <-done:
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		close(done)
+		cancel()
+	}
+}
+
+func (l *Lamp) exit(code int) {
+	if l.Exit != nil {
+		l.Exit(code)
+		return
+	}
+
+	os.Exit(code)
+}
+
+func signalNumber(sig os.Signal) int {
+	if s, ok := sig.(syscall.Signal); ok {
+		return int(s)
+	}
+
+	return 1
+}
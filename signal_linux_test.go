@@ -0,0 +1,82 @@
+package genie
+
+import (
+	"context"
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestLamp_HandleSignals(t *testing.T) {
+	t.Run("validate first signal cancels command context", func(t *testing.T) {
+		want := context.Canceled
+		subject := NewLamp("test", "0.0.0", true)
+		subject.HandleSignals = true
+		subject.Exit = func(code int) {
+			t.Errorf("unexpected exit with %d", code)
+		}
+		subject.RootCommand.Run = func(command *Command) error {
+			if err := syscall.Kill(os.Getpid(), syscall.SIGINT); err != nil {
+				return err
+			}
+
+			select {
+			case <-command.Context().Done():
+				return command.Context().Err()
+			case <-time.After(5 * time.Second):
+				return errors.New("timed out waiting for signal")
+			}
+		}
+
+		_, got := subject.ExecuteWith([]string{"test"})
+		if !errors.Is(got, want) {
+			t.Errorf("want %s, got %s", want, got)
+		}
+	})
+
+	t.Run("validate second signal runs cleanups and exits", func(t *testing.T) {
+		wantCode := 128 + int(syscall.SIGTERM)
+		gotCode := -1
+		cleaned := false
+		exited := make(chan struct{})
+		subject := NewLamp("test", "0.0.0", true)
+		subject.HandleSignals = true
+		subject.Exit = func(code int) {
+			gotCode = code
+			close(exited)
+		}
+		subject.RootCommand.Run = func(command *Command) error {
+			command.OnCleanup(func() {
+				cleaned = true
+			})
+
+			if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+				return err
+			}
+			<-command.Context().Done()
+			if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+				return err
+			}
+
+			select {
+			case <-exited:
+				return nil
+			case <-time.After(5 * time.Second):
+				return errors.New("timed out waiting for exit")
+			}
+		}
+
+		_, err := subject.ExecuteWith([]string{"test"})
+		if err != nil {
+			t.Errorf("[err] want nil, got %s", err)
+		}
+		if !cleaned {
+			t.Error("want cleanup to run, it did not")
+		}
+		if gotCode != wantCode {
+			t.Errorf("want %d, got %d", wantCode, gotCode)
+		}
+	})
+}
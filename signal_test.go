@@ -0,0 +1,123 @@
+package genie
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"testing"
+)
+
+func TestCommand_OnCleanup(t *testing.T) {
+	t.Run("validate cleanups run in reverse order after command runs", func(t *testing.T) {
+		b := bytes.NewBufferString("")
+		want := "command ran second first"
+		subject := &Command{
+			Name: "command",
+			Out:  b,
+			Err:  b,
+			Run: func(command *Command) error {
+				command.OnCleanup(func() {
+					command.Out.Write([]byte(" first"))
+				})
+				command.OnCleanup(func() {
+					command.Out.Write([]byte(" second"))
+				})
+				command.OnCleanup(nil)
+				command.Out.Write([]byte("command ran"))
+				return nil
+			},
+		}
+
+		err := subject.run([]string{})
+		if err != nil {
+			t.Errorf("[err] want nil, got %s", err)
+		}
+		got, err := ioutil.ReadAll(b)
+		if err != nil {
+			t.Errorf("[err] want nil, got %s", err)
+		}
+		if string(got) != want {
+			t.Errorf("want: %s, got %s", want, string(got))
+		}
+	})
+
+	t.Run("validate cleanups run after lamp executes command", func(t *testing.T) {
+		b := bytes.NewBufferString("")
+		want := "command ran cleaned"
+		subject := NewLamp("test", "0.0.0", true)
+		subject.RootCommand.SubCommands = []*Command{
+			{
+				Name: "command",
+				Run: func(command *Command) error {
+					command.OnCleanup(func() {
+						command.Out.Write([]byte(" cleaned"))
+					})
+					command.Out.Write([]byte("command ran"))
+					return nil
+				},
+			},
+		}
+		subject.SetWriters(b, b)
+
+		_, err := subject.ExecuteWith([]string{"test", "command"})
+		if err != nil {
+			t.Errorf("[err] want nil, got %s", err)
+		}
+		got, err := ioutil.ReadAll(b)
+		if err != nil {
+			t.Errorf("[err] want nil, got %s", err)
+		}
+		if string(got) != want {
+			t.Errorf("want: %s, got %s", want, string(got))
+		}
+	})
+}
+
+func Test_cleanups(t *testing.T) {
+	t.Run("validate cleanups only run once", func(t *testing.T) {
+		count := 0
+		subject := &cleanups{}
+		subject.add(func() {
+			count++
+		})
+
+		subject.run()
+		subject.run()
+		if count != 1 {
+			t.Errorf("want 1, got %d", count)
+		}
+	})
+
+	t.Run("validate cleanups are found in context", func(t *testing.T) {
+		want := &cleanups{}
+		got, ok := cleanupsFromContext(context.WithValue(context.Background(), cleanupsKey{}, want))
+		if !ok {
+			t.Error("want true, got false")
+		}
+		if got != want {
+			t.Errorf("want %v, got %v", want, got)
+		}
+
+		_, ok = cleanupsFromContext(context.Background())
+		if ok {
+			t.Error("want false, got true")
+		}
+	})
+}
+
+func TestLamp_exit(t *testing.T) {
+	t.Run("validate exit func is called", func(t *testing.T) {
+		got := -1
+		subject := &Lamp{
+			Name: "test",
+			Exit: func(code int) {
+				got = code
+			},
+		}
+
+		subject.exit(3)
+		if got != 3 {
+			t.Errorf("want 3, got %d", got)
+		}
+	})
+}
@@ -0,0 +1,126 @@
+package genie
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SuggestionDistance is the maximum edit distance between an unknown name and a known one for the known name to be
+// suggested, short names are allowed at most half their length in edits. Names starting with the unknown name are
+// always suggested. Set to a negative value to disable suggestions.
+var SuggestionDistance = 2
+
+// suggestSubCommands returns the names of subcommands similar to name, secret commands are never suggested.
+func (c *Command) suggestSubCommands(name string) []string {
+	var candidates []suggestion
+	for _, sc := range c.SubCommands {
+		if sc.Secret {
+			continue
+		}
+
+		best := -1
+		for _, candidate := range append([]string{sc.Name}, sc.Aliases...) {
+			if d, ok := similar(name, candidate); ok && (best < 0 || d < best) {
+				best = d
+			}
+		}
+		if best >= 0 {
+			candidates = append(candidates, suggestion{name: sc.Name, distance: best})
+		}
+	}
+
+	return sortSuggestions(candidates)
+}
+
+type suggestion struct {
+	name     string
+	distance int
+}
+
+func sortSuggestions(candidates []suggestion) []string {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].distance == candidates[j].distance {
+			return candidates[i].name < candidates[j].name
+		}
+		return candidates[i].distance < candidates[j].distance
+	})
+
+	var names []string
+	for _, c := range candidates {
+		names = append(names, c.name)
+	}
+
+	return names
+}
+
+// similar returns the edit distance between name and candidate, and true if candidate should be suggested for name.
+func similar(name, candidate string) (int, bool) {
+	if SuggestionDistance < 0 || name == "" {
+		return 0, false
+	}
+
+	name = strings.ToLower(name)
+	candidate = strings.ToLower(candidate)
+	distance := SuggestionDistance
+	if half := (len([]rune(name)) + 1) / 2; half < distance {
+		distance = half
+	}
+
+	d := levenshtein(name, candidate)
+	return d, d <= distance || strings.HasPrefix(candidate, name)
+}
+
+// levenshtein returns the minimum number of single character edits needed to change a into b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min3(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(rb)]
+}
+
+func min3(a, b, c int) int {
+	m := a
+	if b < m {
+		m = b
+	}
+	if c < m {
+		m = c
+	}
+
+	return m
+}
+
+// didYouMean returns the suggestion suffix for error messages, each suggestion is formatted with format.
+func didYouMean(suggestions []string, format string) string {
+	if len(suggestions) == 0 {
+		return ""
+	}
+
+	quoted := make([]string, len(suggestions))
+	for i, s := range suggestions {
+		quoted[i] = fmt.Sprintf(format, s)
+	}
+
+	if len(quoted) == 1 {
+		return fmt.Sprintf("; did you mean %s?", quoted[0])
+	}
+
+	return fmt.Sprintf("; did you mean %s or %s?", strings.Join(quoted[:len(quoted)-1], ", "), quoted[len(quoted)-1])
+}
@@ -0,0 +1,201 @@
+package genie
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCommand_suggestSubCommands(t *testing.T) {
+	subject := &Command{
+		Name: "magic",
+		SubCommands: []*Command{
+			{Name: "wish"},
+			{Name: "wash"},
+			{Name: "grant", Aliases: []string{"give"}},
+			{Name: "wisher", Secret: true},
+			{Name: "remove"},
+		},
+	}
+
+	testCases := []struct {
+		name string
+		want []string
+	}{
+		{name: "wsh", want: []string{"wash", "wish"}},
+		{name: "wis", want: []string{"wish", "wash"}},
+		{name: "gve", want: []string{"grant"}},
+		{name: "WISH", want: []string{"wish", "wash"}},
+		{name: "rem", want: []string{"remove"}},
+		{name: "zzzzzz", want: nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := subject.suggestSubCommands(tc.name)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+
+	t.Run("validate suggestions disabled", func(t *testing.T) {
+		old := SuggestionDistance
+		defer func() { SuggestionDistance = old }()
+		SuggestionDistance = -1
+
+		got := subject.suggestSubCommands("wsh")
+		if got != nil {
+			t.Errorf("want nil, got %v", got)
+		}
+	})
+}
+
+func Test_levenshtein(t *testing.T) {
+	testCases := []struct {
+		a, b string
+		want int
+	}{
+		{a: "", b: "", want: 0},
+		{a: "wish", b: "", want: 4},
+		{a: "", b: "wish", want: 4},
+		{a: "wish", b: "wish", want: 0},
+		{a: "wsh", b: "wish", want: 1},
+		{a: "kitten", b: "sitting", want: 3},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.a+"-"+tc.b, func(t *testing.T) {
+			got := levenshtein(tc.a, tc.b)
+			if got != tc.want {
+				t.Errorf("want %d, got %d", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestLamp_ExecuteWith_suggestions(t *testing.T) {
+	t.Run("validate mistyped command on runnable root returns suggestions", func(t *testing.T) {
+		want := `unknown command "wsh" for "magic"; did you mean "wish"?`
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.Run = func(command *Command) error {
+			return nil
+		}
+		subject.RootCommand.SubCommands = []*Command{
+			{
+				Name: "wish",
+				Run: func(command *Command) error {
+					return nil
+				},
+			},
+		}
+
+		gotCommand, got := subject.ExecuteWith([]string{"magic", "wsh"})
+		var notFound *CommandNotFoundError
+		if !errors.As(got, &notFound) {
+			t.Fatalf("want *CommandNotFoundError, got %T", got)
+		}
+		if got.Error() != want {
+			t.Errorf("want %s, got %s", want, got)
+		}
+		if gotCommand != subject.RootCommand {
+			t.Errorf("want %v, got %v", subject.RootCommand, gotCommand)
+		}
+	})
+
+	t.Run("validate dissimilar argument on runnable root is passed on", func(t *testing.T) {
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.Run = func(command *Command) error {
+			return nil
+		}
+		subject.RootCommand.SubCommands = []*Command{
+			{
+				Name: "wish",
+				Run: func(command *Command) error {
+					return nil
+				},
+			},
+		}
+
+		_, got := subject.ExecuteWith([]string{"magic", "genie"})
+		if got != nil {
+			t.Errorf("want nil, got %s", got)
+		}
+	})
+
+	t.Run("validate arguments on root with ArgInfo are passed on", func(t *testing.T) {
+		subject := NewLamp("magic", "0.0.0", true)
+		var gotArgs []string
+		subject.RootCommand.ArgInfo = "[file]"
+		subject.RootCommand.Run = func(command *Command) error {
+			gotArgs = command.Flags.Args()
+			return nil
+		}
+		subject.RootCommand.SubCommands = []*Command{
+			{
+				Name: "ls",
+				Run: func(command *Command) error {
+					return nil
+				},
+			},
+		}
+
+		for _, arg := range []string{"x", "al", "lss", "-"} {
+			_, got := subject.ExecuteWith([]string{"magic", arg})
+			if got != nil {
+				t.Fatalf("%s: want nil, got %s", arg, got)
+			}
+			if !reflect.DeepEqual(gotArgs, []string{arg}) {
+				t.Errorf("want %v, got %v", []string{arg}, gotArgs)
+			}
+		}
+	})
+
+	t.Run("validate short argument on runnable root is only suggested within half its length", func(t *testing.T) {
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.Run = func(command *Command) error {
+			return nil
+		}
+		subject.RootCommand.SubCommands = []*Command{
+			{
+				Name: "ls",
+				Run: func(command *Command) error {
+					return nil
+				},
+			},
+		}
+
+		for _, arg := range []string{"x", "al", "-"} {
+			_, got := subject.ExecuteWith([]string{"magic", arg})
+			if got != nil {
+				t.Errorf("%s: want nil, got %s", arg, got)
+			}
+		}
+
+		_, got := subject.ExecuteWith([]string{"magic", "lss"})
+		if !errors.Is(got, ErrCommandNotFound) {
+			t.Errorf("want %s, got %v", ErrCommandNotFound, got)
+		}
+	})
+
+	t.Run("validate similar argument on root accepting args is passed on", func(t *testing.T) {
+		subject := NewLamp("magic", "0.0.0", true)
+		subject.RootCommand.Run = func(command *Command) error {
+			return nil
+		}
+		subject.RootCommand.SubCommands = []*Command{
+			{
+				Name: "wish",
+				Run: func(command *Command) error {
+					return nil
+				},
+			},
+		}
+		subject.RootCommand.ValidateArgs = MaxArgs(1)
+
+		_, got := subject.ExecuteWith([]string{"magic", "wsh"})
+		if got != nil {
+			t.Errorf("want nil, got %s", got)
+		}
+	})
+}
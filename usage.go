@@ -23,7 +23,11 @@ var DefaultCommandUsageFunc = func(command *Command) string {
 	if command.path == "" {
 		command.path = command.Name
 	}
-	syntax := fmt.Sprintf("%s %s", command.path, strings.ReplaceAll(command.RunSyntax, "{{path}}", command.path))
+	runSyntax := command.RunSyntax
+	if runSyntax == "" && len(command.Args) > 0 {
+		runSyntax = command.argsSyntax()
+	}
+	syntax := fmt.Sprintf("%s %s", command.path, strings.ReplaceAll(runSyntax, "{{path}}", command.path))
 	builder.WriteString(fmt.Sprintf("%s\n", strings.Trim(syntax, " ")))
 
 	if len(command.Aliases) > 0 {
@@ -42,12 +46,21 @@ var DefaultCommandUsageFunc = func(command *Command) string {
 	} else {
 		builder.WriteString(flagsUsage(command))
 	}
+	builder.WriteString(globalFlagsUsage(command))
 
-	if command.ArgInfo != "" {
+	if len(command.Args) > 0 || command.ArgInfo != "" {
 		builder.WriteString("\nARGUMENTS:\n")
+		builder.WriteString(command.argsUsage("%s"))
+	}
+	if command.ArgInfo != "" {
 		builder.WriteString(fmt.Sprintf("%s\n", command.ArgInfo))
 	}
 
+	if env := envUsage(command, "%s", "%s"); env != "" {
+		builder.WriteString("\nENVIRONMENT:\n")
+		builder.WriteString(env)
+	}
+
 	tabWriter := tabwriter.NewWriter(&builder, 0, 0, 4, ' ', tabwriter.DiscardEmptyColumns)
 	wroteCommandHeader := false
 	if len(command.SubCommands) > 0 {
@@ -77,9 +90,9 @@ var DefaultCommandUsageFunc = func(command *Command) string {
 var DefaultFlagsUsageFunc = func(command *Command) string {
 	//we'll remove the leading newline because in this context it's not needed
 	if command.MergeFlagUsage {
-		return strings.TrimPrefix(mergeFlagsUsage(command), "\n")
+		return strings.TrimPrefix(mergeFlagsUsage(command)+globalFlagsUsage(command), "\n")
 	}
-	return strings.TrimPrefix(flagsUsage(command), "\n")
+	return strings.TrimPrefix(flagsUsage(command)+globalFlagsUsage(command), "\n")
 }
 
 func mergeFlagsUsage(command *Command) string {
@@ -89,7 +102,7 @@ func mergeFlagsUsage(command *Command) string {
 	tabWriter := tabwriter.NewWriter(&builder, 0, 0, 4, ' ', tabwriter.DiscardEmptyColumns)
 	if command.Flags != nil {
 		command.Flags.VisitAll(func(f *flag.Flag) {
-			if command.flagIsSecret(f.Name) {
+			if command.flagIsSecret(f.Name) || command.globalFlags[f.Name] || command.isShorthand(f.Name) {
 				return
 			}
 			defaultVal := ""
@@ -97,34 +110,10 @@ func mergeFlagsUsage(command *Command) string {
 				defaultVal = fmt.Sprintf(" (default %s)", f.DefValue)
 			}
 
-			typeOf := ""
-			switch fmt.Sprintf("%T", f.Value) {
-			case "*flag.boolValue":
-				typeOf = ""
-			case "*flag.durationValue":
-				typeOf = "duration"
-			case "*flag.float64Value":
-				typeOf = "float"
-			case "*flag.intValue", "*flag.int64Value":
-				typeOf = "int"
-			case "*flag.stringValue":
-				typeOf = "string"
-			case "*flag.uintValue", "*flag.uint64Value":
-				typeOf = "uint"
-			default:
-				u, ok := f.Value.(UsageAwareFlagValue)
-				if ok {
-					typeOf = u.Type()
-				}
-			}
+			typeOf := flagType(f)
 
-			dashes := "--"
-			if len(f.Name) == 1 {
-				dashes = "-"
-			}
-
-			usage := fmt.Sprintf("%s%s", f.Usage, defaultVal)
-			dashedFlag := fmt.Sprintf("%s%s", dashes, f.Name)
+			usage := fmt.Sprintf("%s%s%s", f.Usage, defaultVal, command.requiredMarker(f.Name))
+			dashedFlag := command.flagUsageName(f.Name)
 
 			existingFlags, exists := usages[usage]
 			if exists {
@@ -173,7 +162,7 @@ func flagsUsage(command *Command) string {
 	builder.WriteString("\nFLAGS:\n") //all commands have at least --help
 	if command.Flags != nil {
 		command.Flags.VisitAll(func(f *flag.Flag) {
-			if command.flagIsSecret(f.Name) {
+			if command.flagIsSecret(f.Name) || command.globalFlags[f.Name] || command.isShorthand(f.Name) {
 				return
 			}
 
@@ -183,32 +172,12 @@ func flagsUsage(command *Command) string {
 				defaultVal = fmt.Sprintf(" (default %s)", f.DefValue)
 			}
 
-			typeOf := ""
-			switch fmt.Sprintf("%T", f.Value) {
-			case "*flag.boolValue":
-				typeOf = ""
-			case "*flag.durationValue":
-				typeOf = " duration"
-			case "*flag.float64Value":
-				typeOf = " float"
-			case "*flag.intValue", "*flag.int64Value":
-				typeOf = " int"
-			case "*flag.stringValue":
-				typeOf = " string"
-			case "*flag.uintValue", "*flag.uint64Value":
-				typeOf = " uint"
-			default:
-				u, ok := f.Value.(UsageAwareFlagValue)
-				if ok {
-					typeOf = fmt.Sprintf(" %s", u.Type())
-				}
+			typeOf := flagType(f)
+			if typeOf != "" {
+				typeOf = " " + typeOf
 			}
 
-			dashes := "--"
-			if len(f.Name) == 1 {
-				dashes = "-"
-			}
-			usages = append(usages, fmt.Sprintf("%s%s\t%s\t%s%s\n", dashes, f.Name, typeOf, f.Usage, defaultVal))
+			usages = append(usages, fmt.Sprintf("%s\t%s\t%s%s%s\n", command.flagUsageName(f.Name), typeOf, f.Usage, defaultVal, command.requiredMarker(f.Name)))
 		})
 	}
 
@@ -232,3 +201,71 @@ func flagsUsage(command *Command) string {
 	_ = tabWriter.Flush()
 	return builder.String()
 }
+
+func globalFlagsUsage(command *Command) string {
+	flags := command.inheritedFlags()
+	if len(flags) == 0 {
+		return ""
+	}
+
+	var builder strings.Builder
+	var usages []string
+	merged := make(map[string]int)
+	tabWriter := tabwriter.NewWriter(&builder, 0, 0, 4, ' ', tabwriter.DiscardEmptyColumns)
+	builder.WriteString("\nGLOBAL FLAGS:\n")
+	for _, f := range flags {
+		defaultVal := ""
+		if f.DefValue != "" {
+			defaultVal = fmt.Sprintf(" (default %s)", f.DefValue)
+		}
+
+		typeOf := flagType(f)
+		if typeOf != "" {
+			typeOf = " " + typeOf
+		}
+
+		usage := fmt.Sprintf("%s%s%s", f.Usage, defaultVal, command.requiredMarker(f.Name))
+		if i, exists := merged[usage]; exists && command.MergeFlagUsage {
+			usages[i] = fmt.Sprintf("%s %s", command.flagUsageName(f.Name), usages[i])
+			continue
+		}
+		merged[usage] = len(usages)
+		usages = append(usages, fmt.Sprintf("%s\t%s\t%s\n", command.flagUsageName(f.Name), typeOf, usage))
+	}
+
+	sort.Slice(usages, func(i, j int) bool {
+		return usages[i] < usages[j]
+	})
+
+	for _, s := range usages {
+		_, _ = tabWriter.Write([]byte(s))
+	}
+
+	_ = tabWriter.Flush()
+	return builder.String()
+}
+
+// flagType returns the type name displayed in usage for the flag, boolean flags have no type displayed.
+func flagType(f *flag.Flag) string {
+	switch fmt.Sprintf("%T", f.Value) {
+	case "*flag.boolValue":
+		return ""
+	case "*flag.durationValue":
+		return "duration"
+	case "*flag.float64Value":
+		return "float"
+	case "*flag.intValue", "*flag.int64Value":
+		return "int"
+	case "*flag.stringValue":
+		return "string"
+	case "*flag.uintValue", "*flag.uint64Value":
+		return "uint"
+	default:
+		u, ok := f.Value.(UsageAwareFlagValue)
+		if ok {
+			return u.Type()
+		}
+	}
+
+	return ""
+}
@@ -359,6 +359,78 @@ FLAGS:
 			t.Errorf("want: %s, got %s", want, string(got))
 		}
 	})
+
+	t.Run("validate default usage - global flags", func(t *testing.T) {
+		want := `A simple wish.
+
+USAGE:
+lamp wish
+
+FLAGS:
+--help               display help for command
+--test     string    the test flag
+
+GLOBAL FLAGS:
+--config      string    the config file
+--verbose               verbose output (default false)
+-v                      verbose output (default false)
+`
+		root := NewCommand("lamp", false)
+		root.PersistentFlags.String("config", "", "the config file")
+		root.PersistentFlags.Bool("verbose", false, "verbose output")
+		root.PersistentFlags.Bool("v", false, "verbose output")
+		root.PersistentFlags.String("hideme", "", "i should not show up")
+		root.SecretFlag("hideme")
+		subject := NewCommand("wish", false)
+		subject.Description = "A simple wish."
+		subject.Flags.String("test", "", "the test flag")
+		root.SubCommands = []*Command{subject}
+		root.AnchorPaths()
+
+		got := subject.ShowUsage()
+		if got != want {
+			t.Errorf("want: %s got: %s", want, got)
+		}
+	})
+
+	t.Run("validate default usage - global flags merged after execution", func(t *testing.T) {
+		want := `A simple wish.
+
+USAGE:
+lamp wish
+
+FLAGS:
+--help               display help for command
+--test     string    the test flag
+
+GLOBAL FLAGS:
+--config         string    the config file
+--verbose -v               verbose output (default false)
+`
+		root := NewCommand("lamp", true)
+		root.PersistentFlags.String("config", "", "the config file")
+		root.PersistentFlags.Bool("verbose", false, "verbose output")
+		root.PersistentFlags.Bool("v", false, "verbose output")
+		subject := NewCommand("wish", true)
+		subject.Description = "A simple wish."
+		subject.MergeFlagUsage = true
+		subject.Flags.String("test", "", "the test flag")
+		subject.Run = func(command *Command) error {
+			return nil
+		}
+		root.SubCommands = []*Command{subject}
+		root.AnchorPaths()
+
+		err := subject.run([]string{"-v", "--config", "genie.json"})
+		if err != nil {
+			t.Errorf("[err] want nil, got %s", err)
+		}
+
+		got := subject.ShowUsage()
+		if got != want {
+			t.Errorf("want: %s got: %s", want, got)
+		}
+	})
 }
 
 func Test_DefaultFlagsUsageFunc(t *testing.T) {